@@ -79,6 +79,21 @@ func BenchmarkParseWithMapEpoch(b *testing.B) {
 	_ = t
 }
 
+func BenchmarkParseWithMapLayout(b *testing.B) {
+	var t time.Time
+	var err error
+	layout := "2006-01-02"
+	value := "2020-11-20"
+
+	for i := 0; i < b.N; i++ {
+		t, err = tparse.ParseWithMap(layout, value, nil)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+	_ = t
+}
+
 func BenchmarkParseWithMapKeyedValue(b *testing.B) {
 	var t time.Time
 	var err error
@@ -0,0 +1,421 @@
+package tparse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// truncateDay returns midnight at the start of the day of t, in the location of t.
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// TruncateWeek returns midnight at the start of the week containing t, where weeks begin on the
+// specified weekStart. When t falls on weekStart, the result is midnight of that same day.
+//
+//	wednesday := time.Date(2020, time.November, 18, 15, 4, 5, 0, time.UTC)
+//	monday := tparse.TruncateWeek(wednesday, time.Monday) // 2020-11-16 00:00:00
+//	sunday := tparse.TruncateWeek(wednesday, time.Sunday) // 2020-11-15 00:00:00
+func TruncateWeek(t time.Time, weekStart time.Weekday) time.Time {
+	days := (int(t.Weekday()) - int(weekStart) + 7) % 7
+	return truncateDay(t).AddDate(0, 0, -days)
+}
+
+// AnchorISOWeek returns midnight at the start of the ISO 8601 week containing t, which is always a
+// Monday, in the location of t. Near the start of a year this may fall in the previous year, in
+// agreement with time.Time.ISOWeek.
+func AnchorISOWeek(t time.Time) time.Time {
+	return TruncateWeek(t, time.Monday)
+}
+
+// AnchorYearStart returns midnight on January 1 of the year of t, in the location of t.
+func AnchorYearStart(t time.Time) time.Time {
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+}
+
+// FractionOfDay returns the time the specified fraction of a day after midnight at the start of the
+// day of t, in the location of t, so a fraction of 0.25 is 6:00 AM and 0.5 is noon. A day is
+// twenty-four hours, even when it spans a daylight saving time transition. It returns an error when
+// fraction is not in the range [0, 1).
+func FractionOfDay(t time.Time, fraction float64) (time.Time, error) {
+	if !(fraction >= 0 && fraction < 1) {
+		return time.Time{}, fmt.Errorf("cannot compute fraction of day: %v is not in the range [0, 1)", fraction)
+	}
+	return truncateDay(t).Add(time.Duration(fraction * float64(24*time.Hour))), nil
+}
+
+// NextTimeOfDay returns the first time strictly after now at which the wall clock in the location of
+// now reads the specified hour, minute, and second: today when that time is still to come, and
+// tomorrow otherwise, including when now is exactly that time, so that a daily job scheduled with
+// the result of one run never runs twice at the same instant. Values outside the range of a clock
+// face are normalized like time.Date does, and a time skipped by a daylight saving time transition
+// is normalized the same way.
+func NextTimeOfDay(now time.Time, hour, min, sec int) time.Time {
+	y, m, d := now.Date()
+	t := time.Date(y, m, d, hour, min, sec, 0, now.Location())
+	if !t.After(now) {
+		t = time.Date(y, m, d+1, hour, min, sec, 0, now.Location())
+	}
+	return t
+}
+
+// truncateMonth returns midnight on the first day of the month of t, in the location of t.
+func truncateMonth(t time.Time) time.Time {
+	y, m, _ := t.Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+}
+
+// EndOfMonth returns the last representable instant of the month of t, one nanosecond before
+// midnight on the first day of the following month, in the location of t.
+func EndOfMonth(t time.Time) time.Time {
+	return truncateMonth(t).AddDate(0, 1, 0).Add(-time.Nanosecond)
+}
+
+// Bucket returns the calendar boundaries of the hour, day, week, month, or year containing t, in
+// the location of t, as named by unit, which is one of "hour", "day", "week", "month", or "year".
+// The start is inclusive and the end is exclusive, being the start of the following bucket, so
+// consecutive buckets share a boundary and t is always in the range [start, end). Unlike the
+// fixed-length time.Time.Truncate, days, months, and years follow the wall clock, and weeks begin on
+// Monday.
+func Bucket(t time.Time, unit string) (start, end time.Time, err error) {
+	switch unit {
+	case "hour":
+		// Subtracting avoids the ambiguity of time.Date during a repeated hour, and unlike
+		// time.Time.Truncate, respects zones offset from UTC by a fraction of an hour.
+		start = t.Add(-(time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second + time.Duration(t.Nanosecond())))
+		return start, start.Add(time.Hour), nil
+	case "day":
+		start = truncateDay(t)
+		return start, start.AddDate(0, 0, 1), nil
+	case "week":
+		start = AnchorISOWeek(t)
+		return start, start.AddDate(0, 0, 7), nil
+	case "month":
+		start = truncateMonth(t)
+		return start, start.AddDate(0, 1, 0), nil
+	case "year":
+		start = AnchorYearStart(t)
+		return start, start.AddDate(1, 0, 0), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("unknown bucket unit %q: expected hour, day, week, month, or year", unit)
+}
+
+var weekdayMap = map[string]time.Weekday{
+	"sun":       time.Sunday,
+	"sunday":    time.Sunday,
+	"mon":       time.Monday,
+	"monday":    time.Monday,
+	"tue":       time.Tuesday,
+	"tuesday":   time.Tuesday,
+	"wed":       time.Wednesday,
+	"wednesday": time.Wednesday,
+	"thu":       time.Thursday,
+	"thursday":  time.Thursday,
+	"fri":       time.Friday,
+	"friday":    time.Friday,
+	"sat":       time.Saturday,
+	"saturday":  time.Saturday,
+}
+
+// relativeWeekday resolves a value that starts with "next" or "last", followed by whitespace and a
+// weekday name or its three letter abbreviation, to midnight of the nearest following or preceding
+// occurrence of that weekday relative to now. When now falls on that weekday, the result is seven
+// days away. It returns the remainder of value after the weekday name, and false when value does not
+// start with such an expression.
+func relativeWeekday(now time.Time, value string) (time.Time, string, bool) {
+	var next bool
+	switch {
+	case hasWord(value, "next"):
+		next = true
+	case hasWord(value, "last"):
+	default:
+		return time.Time{}, value, false
+	}
+	rest := strings.TrimLeft(value[4:], whitespace)
+	var i int
+	for ; i < len(rest) && (rest[i] >= 'a' && rest[i] <= 'z' || rest[i] >= 'A' && rest[i] <= 'Z'); i++ {
+		// letter bytes: no-op
+	}
+	weekday, ok := weekdayMap[strings.ToLower(rest[:i])]
+	if !ok {
+		return time.Time{}, value, false
+	}
+	var days int
+	if next {
+		days = (int(weekday) - int(now.Weekday()) + 7) % 7
+	} else {
+		days = (int(now.Weekday()) - int(weekday) + 7) % 7
+	}
+	if days == 0 {
+		days = 7
+	}
+	if !next {
+		days = -days
+	}
+	return truncateDay(now).AddDate(0, 0, days), rest[i:], true
+}
+
+// calendarAnchors maps the abbreviations for the beginning and end of the day, week, month, and
+// year to functions computing them for a time, with weeks beginning on the specified weekday.
+var calendarAnchors = map[string]func(time.Time, time.Weekday) time.Time{
+	"bod": func(t time.Time, _ time.Weekday) time.Time { return truncateDay(t) },
+	"bow": TruncateWeek,
+	"bom": func(t time.Time, _ time.Weekday) time.Time { return truncateMonth(t) },
+	"boy": func(t time.Time, _ time.Weekday) time.Time { return AnchorYearStart(t) },
+	"eod": func(t time.Time, _ time.Weekday) time.Time {
+		return truncateDay(t).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	},
+	"eow": func(t time.Time, weekStart time.Weekday) time.Time {
+		return TruncateWeek(t, weekStart).AddDate(0, 0, 7).Add(-time.Nanosecond)
+	},
+	"eom": func(t time.Time, _ time.Weekday) time.Time { return EndOfMonth(t) },
+	"eoy": func(t time.Time, _ time.Weekday) time.Time {
+		return AnchorYearStart(t).AddDate(1, 0, 0).Add(-time.Nanosecond)
+	},
+}
+
+// namedAnchors maps the names accepted by AddDurationFrom to the abbreviations of calendarAnchors.
+var namedAnchors = map[string]string{
+	"start-of-day":   "bod",
+	"start-of-week":  "bow",
+	"start-of-month": "bom",
+	"start-of-year":  "boy",
+	"end-of-day":     "eod",
+	"end-of-week":    "eow",
+	"end-of-month":   "eom",
+	"end-of-year":    "eoy",
+}
+
+// AddDurationFrom computes the named anchor from base, then adds the duration string s to it like
+// AddDuration, so AddDurationFrom(base, "start-of-day", "+9h") is nine in the morning on the day of
+// base. The anchor is one of "start-of-day", "start-of-week", "start-of-month", "start-of-year",
+// or the corresponding "end-of-" names, or their abbreviations, such as "bod" and "eom", accepted
+// by ParseNow. Weeks begin on Monday. On error, it returns the base time and the error.
+func AddDurationFrom(base time.Time, anchor, s string) (time.Time, error) {
+	return defaultParser.AddDurationFrom(base, anchor, s)
+}
+
+// AddDurationFrom is like the AddDurationFrom function, but weeks begin on the WeekStart of the
+// Parser, and the duration is parsed using its options.
+func (p *Parser) AddDurationFrom(base time.Time, anchor, s string) (time.Time, error) {
+	abbreviation, ok := namedAnchors[anchor]
+	if !ok {
+		abbreviation = anchor
+	}
+	resolve, ok := calendarAnchors[abbreviation]
+	if !ok {
+		return base, fmt.Errorf("unknown anchor %q", anchor)
+	}
+	t, err := p.AddDuration(resolve(base, p.weekStart()), s)
+	if err != nil {
+		return base, err
+	}
+	return t, nil
+}
+
+// weekStart returns the WeekStart of the Parser, or Monday when it is nil.
+func (p *Parser) weekStart() time.Weekday {
+	if p.WeekStart != nil {
+		return *p.WeekStart
+	}
+	return time.Monday
+}
+
+// calendarAnchor resolves a value that starts with one of the calendarAnchors abbreviations,
+// followed by the end of value, a sign, or whitespace, to that anchor relative to now, with weeks
+// beginning on weekStart. It returns the remainder of value after the abbreviation, and false when
+// value does not start with one.
+func calendarAnchor(now time.Time, value string, weekStart time.Weekday) (time.Time, string, bool) {
+	if len(value) < 3 {
+		return time.Time{}, value, false
+	}
+	if len(value) > 3 {
+		if c := value[3]; c != '+' && c != '-' && strings.IndexByte(whitespace, c) < 0 {
+			return time.Time{}, value, false
+		}
+	}
+	anchor, ok := calendarAnchors[value[:3]]
+	if !ok {
+		return time.Time{}, value, false
+	}
+	return anchor(now, weekStart), value[3:], true
+}
+
+// dayKeywords maps words naming a day relative to the current day to the number of days from it.
+var dayKeywords = map[string]int{
+	"yesterday": -1,
+	"today":     0,
+	"tomorrow":  1,
+}
+
+// relativeDay resolves a value that starts with one of the dayKeywords, followed by the end of
+// value, a sign, or whitespace, to midnight of that day relative to now. It returns the remainder
+// of value after the keyword, and false when value does not start with one.
+func relativeDay(now time.Time, value string) (time.Time, string, bool) {
+	var i int
+	for ; i < len(value) && value[i] >= 'a' && value[i] <= 'z'; i++ {
+		// letter bytes: no-op
+	}
+	if i < len(value) {
+		if c := value[i]; c != '+' && c != '-' && strings.IndexByte(whitespace, c) < 0 {
+			return time.Time{}, value, false
+		}
+	}
+	days, ok := dayKeywords[value[:i]]
+	if !ok {
+		return time.Time{}, value, false
+	}
+	return truncateDay(now).AddDate(0, 0, days), value[i:], true
+}
+
+// applyClock sets the wall clock time of the day of anchor from a clock time such as "T15:30" or
+// "T09:00:00" at the start of s, which may be preceded by whitespace, and returns the remainder of
+// s. When s does not start with a clock time, it returns anchor and s unchanged. The clock fields
+// must be two digits each, and within the range of a clock face. A 12-hour clock time such as
+// "3:30pm" is also accepted, as applyClock12 describes. On error, it returns a *ParseError relative
+// to input, of which s must be a suffix.
+func applyClock(input string, anchor time.Time, s string) (time.Time, string, error) {
+	t := strings.TrimLeft(s, whitespace)
+	if len(t) > 0 && t[0] >= '0' && t[0] <= '9' {
+		return applyClock12(input, anchor, s, t)
+	}
+	if len(t) < 2 || t[0] != 'T' || t[1] < '0' || t[1] > '9' {
+		return anchor, s, nil
+	}
+	var i int
+	for i = 1; i < len(t) && (t[i] >= '0' && t[i] <= '9' || t[i] == ':'); i++ {
+		// clock bytes: no-op
+	}
+	clock := t[:i]
+	fields := strings.Split(clock[1:], ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: expected HH:MM or HH:MM:SS", clock))
+	}
+	limits := []int{23, 59, 59}
+	values := []int{0, 0, 0}
+	for j, field := range fields {
+		if len(field) != 2 {
+			return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: expected HH:MM or HH:MM:SS", clock))
+		}
+		values[j] = int(field[0]-'0')*10 + int(field[1]-'0')
+		if values[j] > limits[j] {
+			return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: field out of range: %q", clock, field))
+		}
+	}
+	y, m, d := anchor.Date()
+	return time.Date(y, m, d, values[0], values[1], values[2], 0, anchor.Location()), t[i:], nil
+}
+
+// applyClock12 is like applyClock for a 12-hour clock time at the start of t, which is s without
+// leading whitespace, such as "3pm", "3:30pm", or "11:59:59PM". The hour is one or two digits from 1
+// through 12, and any minutes and seconds are two digits each. The "am" or "pm" must immediately
+// follow the clock time, and "12am" is midnight while "12pm" is noon. When t does not start with
+// such a clock time, as with the duration "3h", it returns anchor and s unchanged.
+func applyClock12(input string, anchor time.Time, s, t string) (time.Time, string, error) {
+	var i int
+	for i < len(t) && (t[i] >= '0' && t[i] <= '9' || t[i] == ':') {
+		i++
+	}
+	clock, rest := t[:i], t[i:]
+	if len(rest) < 2 {
+		return anchor, s, nil
+	}
+	var pm bool
+	switch strings.ToLower(rest[:2]) {
+	case "am":
+	case "pm":
+		pm = true
+	default:
+		return anchor, s, nil
+	}
+	if rest = rest[2:]; rest != "" && rest[0] != '+' && rest[0] != '-' && strings.IndexByte(whitespace, rest[0]) < 0 {
+		return anchor, s, nil // a longer word, such as "3pms"
+	}
+	fields := strings.Split(clock, ":")
+	if len(fields) > 3 || len(fields[0]) > 2 {
+		return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: expected H, H:MM, or H:MM:SS followed by am or pm", t[:i+2]))
+	}
+	values := []int{0, 0, 0}
+	for j, field := range fields {
+		if field == "" || j > 0 && len(field) != 2 {
+			return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: expected H, H:MM, or H:MM:SS followed by am or pm", t[:i+2]))
+		}
+		for k := 0; k < len(field); k++ {
+			values[j] = 10*values[j] + int(field[k]-'0')
+		}
+		if j == 0 && (values[j] < 1 || values[j] > 12) || j > 0 && values[j] > 59 {
+			return anchor, s, newParseError(input, t, fmt.Errorf("cannot parse clock time %q: field out of range: %q", t[:i+2], field))
+		}
+	}
+	hour := values[0] % 12
+	if pm {
+		hour += 12
+	}
+	y, m, d := anchor.Date()
+	return time.Date(y, m, d, hour, values[1], values[2], 0, anchor.Location()), rest, nil
+}
+
+// monthOffsets maps the words that may qualify "month" in an ordinal day expression to the number
+// of months from the current month.
+var monthOffsets = map[string]int{
+	"last": -1,
+	"this": 0,
+	"next": 1,
+}
+
+// ordinalOfMonth resolves a value that starts with an ordinal day of a month relative to now, such
+// as "1st of next month", "the 15th of this month", or "31st of last month", to midnight of that
+// day. It returns the remainder of value after the expression, and false when value does not start
+// with one. It returns a *ParseError when the day does not exist in that month, such as the 31st of
+// a month with thirty days.
+func ordinalOfMonth(now time.Time, value string) (time.Time, string, bool, error) {
+	s := value
+	if hasWord(s, "the") {
+		s = strings.TrimLeft(s[3:], whitespace)
+	}
+	number := s
+	var day, i int
+	for ; i < len(s) && i < 2 && s[i] >= '0' && s[i] <= '9'; i++ {
+		day = 10*day + int(s[i]-'0')
+	}
+	if i == 0 || len(s) < i+2 {
+		return time.Time{}, value, false, nil
+	}
+	switch s[i : i+2] {
+	case "st", "nd", "rd", "th":
+	default:
+		return time.Time{}, value, false, nil
+	}
+	if s = s[i+2:]; s == "" || strings.IndexByte(whitespace, s[0]) < 0 {
+		return time.Time{}, value, false, nil
+	}
+	if s = strings.TrimLeft(s, whitespace); !hasWord(s, "of") {
+		return time.Time{}, value, false, nil
+	}
+	s = strings.TrimLeft(s[2:], whitespace)
+	var months int
+	var ok bool
+	for word, offset := range monthOffsets {
+		if hasWord(s, word) {
+			months, ok = offset, true
+			s = strings.TrimLeft(s[len(word):], whitespace)
+			break
+		}
+	}
+	if !ok {
+		return time.Time{}, value, false, nil
+	}
+	if !strings.HasPrefix(s, "month") {
+		return time.Time{}, value, false, nil
+	}
+	if rest := s[5:]; rest != "" && rest[0] != '+' && rest[0] != '-' && strings.IndexByte(whitespace, rest[0]) < 0 {
+		return time.Time{}, value, false, nil
+	}
+	first := truncateMonth(now).AddDate(0, months, 0)
+	if last := EndOfMonth(first).Day(); day < 1 || day > last {
+		return time.Time{}, value, true, newParseError(value, number, fmt.Errorf("day %d out of range for %s %d", day, first.Month(), first.Year()))
+	}
+	return first.AddDate(0, 0, day-1), s[5:], true, nil
+}
@@ -0,0 +1,323 @@
+package tparse
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestTruncateWeek(t *testing.T) {
+	// Wednesday
+	wednesday := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	t.Run("monday", func(t *testing.T) {
+		actual := TruncateWeek(wednesday, time.Monday)
+		expected := time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("sunday", func(t *testing.T) {
+		actual := TruncateWeek(wednesday, time.Sunday)
+		expected := time.Date(2020, time.November, 15, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("wraparound", func(t *testing.T) {
+		actual := TruncateWeek(wednesday, time.Friday)
+		expected := time.Date(2020, time.November, 13, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("same day", func(t *testing.T) {
+		actual := TruncateWeek(wednesday, time.Wednesday)
+		expected := time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestAnchorISOWeek(t *testing.T) {
+	t.Run("mid week", func(t *testing.T) {
+		actual := AnchorISOWeek(time.Date(2020, time.November, 19, 15, 4, 5, 6, time.UTC))
+		expected := time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("year boundary", func(t *testing.T) {
+		// Friday, January 1, 2021 is in ISO week 53 of 2020.
+		value := time.Date(2021, time.January, 1, 15, 4, 5, 6, time.UTC)
+		actual := AnchorISOWeek(value)
+		expected := time.Date(2020, time.December, 28, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		ay, aw := actual.ISOWeek()
+		vy, vw := value.ISOWeek()
+		if ay != vy || aw != vw {
+			t.Errorf("Actual: %d-W%d; Expected: %d-W%d", ay, aw, vy, vw)
+		}
+	})
+}
+
+func TestAnchorYearStart(t *testing.T) {
+	t.Run("mid year", func(t *testing.T) {
+		actual := AnchorYearStart(time.Date(2020, time.November, 19, 15, 4, 5, 6, time.UTC))
+		expected := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("year boundary", func(t *testing.T) {
+		actual := AnchorYearStart(time.Date(2020, time.December, 31, 23, 59, 59, 999999999, time.UTC))
+		expected := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestEndOfMonth(t *testing.T) {
+	cases := []struct {
+		value    time.Time
+		expected time.Time
+	}{
+		{time.Date(2021, time.January, 15, 12, 0, 0, 0, time.UTC), time.Date(2021, time.January, 31, 23, 59, 59, 999999999, time.UTC)},
+		{time.Date(2020, time.February, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, time.February, 29, 23, 59, 59, 999999999, time.UTC)},
+		{time.Date(2021, time.February, 28, 23, 59, 59, 999999999, time.UTC), time.Date(2021, time.February, 28, 23, 59, 59, 999999999, time.UTC)},
+		{time.Date(2021, time.April, 30, 6, 0, 0, 0, time.UTC), time.Date(2021, time.April, 30, 23, 59, 59, 999999999, time.UTC)},
+		{time.Date(2020, time.December, 31, 6, 0, 0, 0, time.UTC), time.Date(2020, time.December, 31, 23, 59, 59, 999999999, time.UTC)},
+	}
+
+	for _, c := range cases {
+		if actual := EndOfMonth(c.value); actual != c.expected {
+			t.Errorf("Value: %s; Actual: %s; Expected: %s", c.value, actual, c.expected)
+		}
+	}
+}
+
+func TestFractionOfDay(t *testing.T) {
+	when := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := map[float64]time.Time{
+		0:    time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC),
+		0.25: time.Date(2020, time.November, 18, 6, 0, 0, 0, time.UTC),
+		0.5:  time.Date(2020, time.November, 18, 12, 0, 0, 0, time.UTC),
+	}
+	for fraction, expected := range cases {
+		actual, err := FractionOfDay(when, fraction)
+		ensureError(t, err)
+		if actual != expected {
+			t.Errorf("Fraction: %v; Actual: %s; Expected: %s", fraction, actual, expected)
+		}
+	}
+
+	for _, fraction := range []float64{-0.1, 1, 1.5, math.NaN(), math.Inf(1)} {
+		_, err := FractionOfDay(when, fraction)
+		ensureError(t, err, "not in the range [0, 1)")
+	}
+}
+
+func TestNextTimeOfDay(t *testing.T) {
+	now := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := []struct {
+		name           string
+		hour, min, sec int
+		expected       time.Time
+	}{
+		{"later today", 18, 0, 0, time.Date(2020, time.November, 18, 18, 0, 0, 0, time.UTC)},
+		{"earlier today", 9, 0, 0, time.Date(2020, time.November, 19, 9, 0, 0, 0, time.UTC)},
+		{"within the second", 15, 4, 5, time.Date(2020, time.November, 19, 15, 4, 5, 0, time.UTC)},
+		{"next second", 15, 4, 6, time.Date(2020, time.November, 18, 15, 4, 6, 0, time.UTC)},
+		{"midnight", 0, 0, 0, time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if actual := NextTimeOfDay(now, c.hour, c.min, c.sec); actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("exactly now", func(t *testing.T) {
+		now := time.Date(2020, time.November, 18, 9, 0, 0, 0, time.UTC)
+		if actual, expected := NextTimeOfDay(now, 9, 0, 0), now.AddDate(0, 0, 1); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("end of month", func(t *testing.T) {
+		now := time.Date(2020, time.December, 31, 23, 0, 0, 0, time.UTC)
+		if actual, expected := NextTimeOfDay(now, 9, 0, 0), time.Date(2021, time.January, 1, 9, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		loc := time.FixedZone("EST", -5*3600)
+		now := time.Date(2020, time.November, 18, 8, 0, 0, 0, loc)
+		if actual, expected := NextTimeOfDay(now, 9, 0, 0), time.Date(2020, time.November, 18, 9, 0, 0, 0, loc); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestBucket(t *testing.T) {
+	when := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := []struct {
+		unit       string
+		start, end time.Time
+	}{
+		{"hour", time.Date(2020, time.November, 18, 15, 0, 0, 0, time.UTC), time.Date(2020, time.November, 18, 16, 0, 0, 0, time.UTC)},
+		{"day", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC), time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC)},
+		{"week", time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC), time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC)},
+		{"month", time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"year", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.unit, func(t *testing.T) {
+			start, end, err := Bucket(when, c.unit)
+			ensureError(t, err)
+			if start != c.start {
+				t.Errorf("Start: Actual: %s; Expected: %s", start, c.start)
+			}
+			if end != c.end {
+				t.Errorf("End: Actual: %s; Expected: %s", end, c.end)
+			}
+
+			// The end is exclusive: it starts the following bucket.
+			next, _, err := Bucket(end, c.unit)
+			ensureError(t, err)
+			if next != end {
+				t.Errorf("Next start: Actual: %s; Expected: %s", next, end)
+			}
+		})
+	}
+
+	t.Run("february of leap year", func(t *testing.T) {
+		start, end, err := Bucket(time.Date(2020, time.February, 29, 23, 59, 59, 0, time.UTC), "month")
+		ensureError(t, err)
+		if got, want := end.Sub(start), 29*24*time.Hour; got != want {
+			t.Errorf("GOT: %s; WANT: %s", got, want)
+		}
+	})
+
+	t.Run("fractional hour zone", func(t *testing.T) {
+		loc := time.FixedZone("IST", 5*3600+1800)
+		start, _, err := Bucket(time.Date(2020, time.November, 18, 15, 4, 5, 6, loc), "hour")
+		ensureError(t, err)
+		if expected := time.Date(2020, time.November, 18, 15, 0, 0, 0, loc); !start.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", start, expected)
+		}
+	})
+
+	t.Run("unknown unit", func(t *testing.T) {
+		_, _, err := Bucket(when, "fortnight")
+		ensureError(t, err, `unknown bucket unit "fortnight"`)
+	})
+}
+
+func TestAddDurationFrom(t *testing.T) {
+	// Wednesday
+	base := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := []struct {
+		anchor, duration string
+		expected         time.Time
+	}{
+		{"start-of-day", "+9h", time.Date(2020, time.November, 18, 9, 0, 0, 0, time.UTC)},
+		{"start-of-day", "", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)},
+		{"bod", "+9h", time.Date(2020, time.November, 18, 9, 0, 0, 0, time.UTC)},
+		{"start-of-week", "+1d", time.Date(2020, time.November, 17, 0, 0, 0, 0, time.UTC)},
+		{"start-of-month", "-1mo", time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)},
+		{"start-of-year", "+6mo", time.Date(2020, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"end-of-day", "+1ns", time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC)},
+		{"end-of-month", "", time.Date(2020, time.November, 30, 23, 59, 59, 999999999, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.anchor+c.duration, func(t *testing.T) {
+			actual, err := AddDurationFrom(base, c.anchor, c.duration)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("unknown anchor", func(t *testing.T) {
+		actual, err := AddDurationFrom(base, "start-of-decade", "+1h")
+		ensureError(t, err, `unknown anchor "start-of-decade"`)
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		actual, err := AddDurationFrom(base, "start-of-day", "+9x")
+		ensureError(t, err, `unknown unit "x"`)
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+	})
+}
+
+func TestParserAddDurationFromWeekStart(t *testing.T) {
+	// Wednesday
+	base := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+	sunday, monday := time.Sunday, time.Monday
+
+	cases := []struct {
+		name      string
+		weekStart *time.Weekday
+		anchor    string
+		expected  time.Time
+	}{
+		{"default", nil, "bow", time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)},
+		{"default", nil, "eow", time.Date(2020, time.November, 22, 23, 59, 59, 999999999, time.UTC)},
+		{"monday", &monday, "start-of-week", time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)},
+		{"monday", &monday, "end-of-week", time.Date(2020, time.November, 22, 23, 59, 59, 999999999, time.UTC)},
+		{"sunday", &sunday, "start-of-week", time.Date(2020, time.November, 15, 0, 0, 0, 0, time.UTC)},
+		{"sunday", &sunday, "end-of-week", time.Date(2020, time.November, 21, 23, 59, 59, 999999999, time.UTC)},
+		{"sunday", &sunday, "start-of-month", time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name+" "+c.anchor, func(t *testing.T) {
+			p := Parser{WeekStart: c.weekStart}
+			actual, err := p.AddDurationFrom(base, c.anchor, "")
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("on week start", func(t *testing.T) {
+		p := Parser{WeekStart: &sunday}
+		actual, err := p.AddDurationFrom(time.Date(2020, time.November, 15, 12, 0, 0, 0, time.UTC), "bow", "+9h")
+		ensureError(t, err)
+		if expected := time.Date(2020, time.November, 15, 9, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("parser options", func(t *testing.T) {
+		p := Parser{WeekStart: &sunday, Calendar: true}
+		actual, err := p.AddDurationFrom(base, "bow", "+1w")
+		ensureError(t, err)
+		if expected := time.Date(2020, time.November, 22, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
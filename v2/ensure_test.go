@@ -1,6 +1,7 @@
 package tparse
 
 import (
+	"fmt"
 	"strings"
 	"testing"
 )
@@ -21,3 +22,17 @@ func ensureError(tb testing.TB, err error, contains ...string) {
 		}
 	}
 }
+
+func ensurePanic(tb testing.TB, contains string, callback func()) {
+	tb.Helper()
+	defer func() {
+		r := recover()
+		if r == nil {
+			tb.Fatalf("GOT: %v; WANT: %v", r, contains)
+		}
+		if got := fmt.Sprintf("%v", r); !strings.Contains(got, contains) {
+			tb.Errorf("GOT: %v; WANT: %q", got, contains)
+		}
+	}()
+	callback()
+}
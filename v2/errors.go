@@ -0,0 +1,61 @@
+package tparse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrMalformedNumber is wrapped by the error returned when a number in a duration is malformed, for
+// instance when it has two decimal points, or a decimal point without any digits. Use errors.Is to
+// test for it.
+var ErrMalformedNumber = errors.New("invalid floating point number format")
+
+// ParseError describes a problem parsing a duration, and where in the input string that problem
+// was found.
+type ParseError struct {
+	// Input is the string being parsed.
+	Input string
+
+	// Offset is the byte offset into Input where the problem starts.
+	Offset int
+
+	// Err is the underlying error.
+	Err error
+}
+
+// newParseError returns a ParseError for a problem found at the start of rest, which must be a
+// suffix of input.
+func newParseError(input, rest string, err error) *ParseError {
+	return &ParseError{Input: input, Offset: len(input) - len(rest), Err: err}
+}
+
+// Error returns the underlying error message along with the input and the offset where the problem
+// was found, such as `parsing "now+1h+bogus": unknown unit "bogus" at offset 7`, so that the
+// message can be understood in a log without the input being logged separately.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("parsing %q: %v at offset %d", e.Input, e.Err, e.Offset)
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// Caret returns the input followed by a second line with a caret pointing at the problem, suitable
+// for displaying to users of command line programs.
+//
+//	now+1h+xq
+//	       ^
+func (e *ParseError) Caret() string {
+	column := utf8.RuneCountInString(e.Input[:e.Offset])
+	return e.Input + "\n" + strings.Repeat(" ", column) + "^"
+}
+
+// relocate returns err with its offset adjusted for input, when err is a ParseError for a suffix of
+// input starting at the specified offset. Other errors are returned unchanged.
+func relocate(err error, input string, offset int) error {
+	if e, ok := err.(*ParseError); ok {
+		return &ParseError{Input: input, Offset: offset + e.Offset, Err: e.Err}
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package tparse
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseError(t *testing.T) {
+	t.Run("AddDuration", func(t *testing.T) {
+		_, err := AddDuration(time.Now(), "+1h+xq")
+		e, ok := err.(*ParseError)
+		if !ok {
+			t.Fatalf("GOT: %#v; WANT: %T", err, e)
+		}
+		if got, want := e.Offset, 4; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		ensureError(t, err, `unknown unit "xq"`)
+	})
+
+	t.Run("ParseNow", func(t *testing.T) {
+		_, err := ParseNow("", "now+1h+xq")
+		e, ok := err.(*ParseError)
+		if !ok {
+			t.Fatalf("GOT: %#v; WANT: %T", err, e)
+		}
+		if got, want := e.Input, "now+1h+xq"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := e.Input[e.Offset], byte('x'); got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+		if got, want := e.Caret(), "now+1h+xq\n       ^"; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	})
+
+	t.Run("ParseWithMap", func(t *testing.T) {
+		_, err := ParseWithMap("", "start-1.2.3h", map[string]time.Time{"start": time.Now()})
+		e, ok := err.(*ParseError)
+		if !ok {
+			t.Fatalf("GOT: %#v; WANT: %T", err, e)
+		}
+		if got, want := e.Offset, 9; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+	})
+}
+
+func TestErrMalformedNumber(t *testing.T) {
+	for value, message := range map[string]string{
+		"1.2.3h": `parsing "1.2.3h": invalid floating point number format: two decimal points found at offset 3`,
+		".h":     `parsing ".h": invalid floating point number format: no digits found at offset 0`,
+	} {
+		t.Run(value, func(t *testing.T) {
+			_, err := AddDuration(time.Now(), value)
+			if !errors.Is(err, ErrMalformedNumber) {
+				t.Errorf("GOT: %v; WANT: %v", err, ErrMalformedNumber)
+			}
+			if err == nil || err.Error() != message {
+				t.Errorf("GOT: %v; WANT: %s", err, message)
+			}
+		})
+	}
+
+	t.Run("other errors", func(t *testing.T) {
+		_, err := AddDuration(time.Now(), "1x")
+		if errors.Is(err, ErrMalformedNumber) {
+			t.Errorf("GOT: %v; WANT: not %v", err, ErrMalformedNumber)
+		}
+	})
+}
+
+func TestParseErrorMessage(t *testing.T) {
+	_, err := ParseNow("", "now+1h+bogus")
+	if got, want := err.Error(), `parsing "now+1h+bogus": unknown unit "bogus" at offset 7`; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+
+	var e *ParseError
+	if !errors.As(err, &e) {
+		t.Fatalf("GOT: %#v; WANT: %T", err, e)
+	}
+	if got, want := e.Input, "now+1h+bogus"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+
+	_, err = ParseNow("", "now+1.2.3h")
+	ensureError(t, err, `parsing "now+1.2.3h"`, "two decimal points")
+	if !errors.Is(err, ErrMalformedNumber) {
+		t.Errorf("GOT: %v; WANT: %v", err, ErrMalformedNumber)
+	}
+}
@@ -0,0 +1,47 @@
+package tparse
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Expand returns text with each expression delimited by a pair of '@' characters, such as
+// "@now-1h@", replaced by the time it resolves to, formatted using time.RFC3339. Expressions are
+// parsed like Between does, and those relative to `now` are all resolved against the same instant.
+// A doubled "@@" outside an expression is replaced by a single literal '@'. When an expression is
+// not terminated or cannot be parsed, the error includes the byte offset of its opening '@' in
+// text, and wraps the error from parsing it.
+//
+//	s, err := tparse.Expand("SELECT * FROM events WHERE ts > '@now-1h@'", "", nil)
+func Expand(text string, layout string, dict map[string]time.Time) (string, error) {
+	now := time.Now()
+	var sb strings.Builder
+	var start int // index in text of the first byte not yet written
+	for start < len(text) {
+		i := strings.IndexByte(text[start:], '@')
+		if i < 0 {
+			break
+		}
+		i += start
+		sb.WriteString(text[start:i])
+		if strings.HasPrefix(text[i+1:], "@") {
+			sb.WriteByte('@')
+			start = i + 2
+			continue
+		}
+		j := strings.IndexByte(text[i+1:], '@')
+		if j < 0 {
+			return "", fmt.Errorf("cannot expand expression at offset %d: unterminated expression", i)
+		}
+		j += i + 1
+		t, err := parseNow(now, layout, text[i+1:j], dict, nil, nil)
+		if err != nil {
+			return "", fmt.Errorf("cannot expand expression at offset %d: %w", i, err)
+		}
+		sb.WriteString(t.Format(time.RFC3339))
+		start = j + 1
+	}
+	sb.WriteString(text[start:])
+	return sb.String(), nil
+}
@@ -0,0 +1,76 @@
+package tparse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpand(t *testing.T) {
+	dict := map[string]time.Time{
+		"start": time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("no expressions", func(t *testing.T) {
+		actual, err := Expand("nothing to see here", "", dict)
+		ensureError(t, err)
+		if expected := "nothing to see here"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("one expression", func(t *testing.T) {
+		actual, err := Expand("from @start+1h@ on", "", dict)
+		ensureError(t, err)
+		if expected := "from 2009-11-11T00:00:00Z on"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("several expressions", func(t *testing.T) {
+		actual, err := Expand("@start@..@start+1d@", "", dict)
+		ensureError(t, err)
+		if expected := "2009-11-10T23:00:00Z..2009-11-11T23:00:00Z"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("now", func(t *testing.T) {
+		actual, err := Expand("@now@ @now-1h@", "", nil)
+		ensureError(t, err)
+		fields := strings.Fields(actual)
+		if len(fields) != 2 {
+			t.Fatalf("Actual: %q; Expected: two times", actual)
+		}
+		now, err := time.Parse(time.RFC3339, fields[0])
+		ensureError(t, err)
+		earlier, err := time.Parse(time.RFC3339, fields[1])
+		ensureError(t, err)
+		if actual, expected := now.Sub(earlier), time.Hour; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("escaped", func(t *testing.T) {
+		actual, err := Expand("user@@example.com at @start@", "", dict)
+		ensureError(t, err)
+		if expected := "user@example.com at 2009-11-10T23:00:00Z"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("unterminated", func(t *testing.T) {
+		_, err := Expand("from @start+1h on", "", dict)
+		ensureError(t, err, "offset 5", "unterminated expression")
+	})
+
+	t.Run("invalid expression", func(t *testing.T) {
+		_, err := Expand("@@ @start+1x@", "", dict)
+		ensureError(t, err, "offset 3")
+		var pe *ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Actual: %#v; Expected: %T", err, pe)
+		}
+	})
+}
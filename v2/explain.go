@@ -0,0 +1,116 @@
+package tparse
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Explanation describes how ParseExplain resolved a value.
+type Explanation struct {
+	// Anchor is the name of the time the value was resolved relative to: "now", a key in the dict,
+	// "next <weekday>" or "last <weekday>" as written, "epoch" for the epoch keyword and epoch
+	// values, or "layout" when the value was parsed using the layout.
+	Anchor string
+
+	// Offset is the duration string following the anchor, as written, or the empty string when the
+	// value has no offset.
+	Offset string
+}
+
+// set records the anchor and offset, and is a no-op on a nil Explanation, which allows parsing
+// functions to record their work only when a caller asked for it.
+func (x *Explanation) set(anchor, offset string) {
+	if x != nil {
+		x.Anchor, x.Offset = anchor, strings.TrimLeft(offset, whitespace)
+	}
+}
+
+// ParseExplain is like ParseNow, but also accepts a dict like ParseWithMap, and returns an
+// Explanation of how the value was resolved alongside the time, for instance to record in an audit
+// log that "now-1h" is one hour before the current time. On error, it returns a zero Explanation.
+func ParseExplain(layout, value string, dict map[string]time.Time) (time.Time, Explanation, error) {
+	var x Explanation
+	t, err := parseNow(time.Now(), layout, value, dict, nil, &x)
+	if err != nil {
+		return t, Explanation{}, err
+	}
+	return t, x, nil
+}
+
+// Kind is the kind of time a value is resolved relative to, as reported by Classify.
+type Kind int
+
+const (
+	// KindLayout is a value parsed using the layout.
+	KindLayout Kind = iota + 1
+
+	// KindEpoch is a value relative to the Unix epoch: an epoch value, possibly followed by a
+	// duration, or a value starting with `epoch` or '@'.
+	KindEpoch
+
+	// KindNow is a value relative to the current time, such as "now-1h", "today", "next monday",
+	// "1st of next month", or "bom".
+	KindNow
+
+	// KindDict is a value relative to a key in the dict.
+	KindDict
+)
+
+// String returns the name of the kind, such as "epoch".
+func (k Kind) String() string {
+	switch k {
+	case KindLayout:
+		return "layout"
+	case KindEpoch:
+		return "epoch"
+	case KindNow:
+		return "now"
+	case KindDict:
+		return "dict"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Classify returns the kind of value, for instance to choose between an editor for relative times
+// and one for absolute times. The value is resolved like ParseExplain does, so that its kind is the
+// one used to parse it, and an error is returned when it cannot be parsed.
+func Classify(layout, value string, dict map[string]time.Time) (Kind, error) {
+	now := time.Now()
+	var x Explanation
+	if _, err := parseNow(now, layout, value, dict, nil, &x); err != nil {
+		return 0, err
+	}
+	// The dict is consulted before any other interpretation, so a value starting with a key was
+	// resolved using the key, even when it also starts with a word such as "now" or "today".
+	if longestKey(value, dict) != "" {
+		return KindDict, nil
+	}
+	if relativeToNow(now, value) {
+		return KindNow, nil
+	}
+	if x.Anchor == "epoch" {
+		return KindEpoch, nil
+	}
+	return KindLayout, nil
+}
+
+// relativeToNow returns true when parseNow resolves a value that does not start with a key in the
+// dict relative to now, rather than passing it on to be parsed as an epoch value or using the
+// layout.
+func relativeToNow(now time.Time, value string) bool {
+	if strings.HasPrefix(value, "now") {
+		return true
+	}
+	if _, _, ok := relativeDay(now, value); ok {
+		return true
+	}
+	if _, _, ok := relativeWeekday(now, value); ok {
+		return true
+	}
+	if _, _, ok, _ := ordinalOfMonth(now, value); ok {
+		return true
+	}
+	_, _, ok := calendarAnchor(now, value, time.Monday)
+	return ok
+}
@@ -0,0 +1,138 @@
+package tparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseExplain(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	dict := map[string]time.Time{"start": start}
+
+	cases := []struct {
+		value  string
+		anchor string
+		offset string
+	}{
+		{"now-1h", "now", "-1h"},
+		{"now", "now", ""},
+		{"start+30m", "start", "+30m"},
+		{"next monday +9h", "next monday", "+9h"},
+		{"epoch+1d", "epoch", "+1d"},
+		{"1257894000", "epoch", ""},
+		{"1257894000ms", "epoch", ""},
+		{"1257894000-1h", "epoch", "-1h"},
+		{"2009-11-10T23:00:00Z", "layout", ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			_, x, err := ParseExplain(time.RFC3339, c.value, dict)
+			ensureError(t, err)
+			if x.Anchor != c.anchor {
+				t.Errorf("Anchor: Actual: %q; Expected: %q", x.Anchor, c.anchor)
+			}
+			if x.Offset != c.offset {
+				t.Errorf("Offset: Actual: %q; Expected: %q", x.Offset, c.offset)
+			}
+		})
+	}
+
+	t.Run("time", func(t *testing.T) {
+		actual, _, err := ParseExplain(time.RFC3339, "start+30m", dict)
+		ensureError(t, err)
+		if expected := start.Add(30 * time.Minute); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, x, err := ParseExplain(time.RFC3339, "now-1x", dict)
+		ensureError(t, err, `unknown unit "x"`)
+		if x != (Explanation{}) {
+			t.Errorf("Actual: %#v; Expected: %#v", x, Explanation{})
+		}
+	})
+}
+
+func TestClassify(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	dict := map[string]time.Time{"start": start, "epoch": start, "todayish": start}
+
+	cases := []struct {
+		value    string
+		expected Kind
+	}{
+		{"now", KindNow},
+		{"now-1h", KindNow},
+		{"today", KindNow},
+		{"tomorrow 9am", KindNow},
+		{"next monday +9h", KindNow},
+		{"1st of next month", KindNow},
+		{"bom+1d", KindNow},
+		{"start", KindDict},
+		{"start+30m", KindDict},
+		{"epoch+1d", KindDict},
+		{"todayish", KindDict},
+		{"1257894000", KindEpoch},
+		{"1257894000.5", KindEpoch},
+		{"1257894000ms", KindEpoch},
+		{"1257894000-1h", KindEpoch},
+		{"@1257894000", KindEpoch},
+		{"2009-11-10T23:00:00Z", KindLayout},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := Classify(time.RFC3339, c.value, dict)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("keys named like anchors", func(t *testing.T) {
+		dict := map[string]time.Time{"today": start, "now_deploy": start}
+		for value, expected := range map[string]Kind{
+			"today":         KindDict,
+			"today+1h":      KindDict,
+			"now_deploy+1h": KindDict,
+			"now+1h":        KindNow,
+			"tomorrow":      KindNow,
+		} {
+			actual, err := Classify(time.RFC3339, value, dict)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+			}
+		}
+	})
+
+	t.Run("epoch keyword", func(t *testing.T) {
+		actual, err := Classify(time.RFC3339, "epoch+1d", nil)
+		ensureError(t, err)
+		if expected := KindEpoch; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		for _, value := range []string{"now+1x", "start+1x", "bogus"} {
+			actual, err := Classify(time.RFC3339, value, dict)
+			if err == nil {
+				t.Errorf("%s: GOT: %s; WANT: error", value, actual)
+			}
+			if actual != 0 {
+				t.Errorf("%s: Actual: %s; Expected: %s", value, actual, Kind(0))
+			}
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		for kind, expected := range map[Kind]string{KindLayout: "layout", KindEpoch: "epoch", KindNow: "now", KindDict: "dict", 0: "Kind(0)"} {
+			if actual := kind.String(); actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		}
+	})
+}
@@ -0,0 +1,21 @@
+package tparse
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ParseRelativeToFile returns the modification time of the named file, adjusted by the duration
+// string in value, which is parsed like AddDuration. An empty value returns the modification time
+// itself. When the file cannot be stat'ed, the returned error wraps the error from os.Stat.
+//
+//	// one day after the log was last written
+//	t, err := tparse.ParseRelativeToFile("/var/log/messages", "+1d")
+func ParseRelativeToFile(path, value string) (time.Time, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse relative to file: %w", err)
+	}
+	return AddDuration(fi.ModTime(), value)
+}
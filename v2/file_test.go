@@ -0,0 +1,56 @@
+package tparse
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseRelativeToFile(t *testing.T) {
+	fh, err := ioutil.TempFile("", "tparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := fh.Name()
+	defer os.Remove(path)
+	if err = fh.Close(); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	if err = os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("duration", func(t *testing.T) {
+		actual, err := ParseRelativeToFile(path, "+1h")
+		ensureError(t, err)
+		if expected := mtime.Add(time.Hour); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		actual, err := ParseRelativeToFile(path, "")
+		ensureError(t, err)
+		if !actual.Equal(mtime) {
+			t.Errorf("Actual: %s; Expected: %s", actual, mtime)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := ParseRelativeToFile(path, "+1x")
+		ensureError(t, err, `unknown unit "x"`)
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := ParseRelativeToFile(filepath.Join(path, "missing"), "+1h")
+		ensureError(t, err, "cannot parse relative to file")
+		var pe *os.PathError
+		if !errors.As(err, &pe) {
+			t.Errorf("GOT: %#v; WANT: %T", err, pe)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package tparse
+
+import "time"
+
+// TimeValue implements flag.Value, allowing time expressions accepted by ParseNow, such as
+// "now-1h", to be used as command line flags. The expression is resolved when the flag is parsed.
+//
+//	since := tparse.TimeValue{Time: time.Now().Add(-time.Hour)}
+//	flag.Var(&since, "since", "only show events after this time")
+//	flag.Parse()
+//	fmt.Println(since.Time)
+type TimeValue struct {
+	// Layout is used to parse absolute times and to format the resolved time. When empty,
+	// time.RFC3339 is used.
+	Layout string
+
+	// Time is the resolved time.
+	Time time.Time
+}
+
+func (v *TimeValue) layout() string {
+	if v.Layout != "" {
+		return v.Layout
+	}
+	return time.RFC3339
+}
+
+// Set resolves the expression using ParseNow, and stores the resulting time.
+func (v *TimeValue) Set(value string) error {
+	t, err := ParseNow(v.layout(), value)
+	if err != nil {
+		return err
+	}
+	v.Time = t
+	return nil
+}
+
+// String returns the resolved time formatted using the layout, or the empty string when no time
+// has been set.
+func (v *TimeValue) String() string {
+	if v == nil || v.Time.IsZero() {
+		return ""
+	}
+	return v.Time.Format(v.layout())
+}
+
+// Get returns the resolved time.Time, implementing flag.Getter.
+func (v *TimeValue) Get() interface{} { return v.Time }
@@ -0,0 +1,51 @@
+package tparse
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestTimeValue(t *testing.T) {
+	var since TimeValue
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&since, "since", "only show events after this time")
+
+	before := time.Now().Add(-time.Hour)
+	err := fs.Parse([]string{"-since", "now-1h"})
+	ensureError(t, err)
+	after := time.Now().Add(-time.Hour)
+
+	if before.After(since.Time) || since.Time.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", since.Time, before, after)
+	}
+	if got, want := since.String(), since.Time.Format(time.RFC3339); got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
+
+func TestTimeValueSetError(t *testing.T) {
+	since := TimeValue{Layout: "2006-01-02"}
+
+	err := since.Set("now-1xq")
+	ensureError(t, err, `unknown unit "xq"`)
+
+	err = since.Set("yesteryear")
+	ensureError(t, err, "yesteryear")
+
+	if !since.Time.IsZero() {
+		t.Errorf("GOT: %s; WANT: zero time", since.Time)
+	}
+}
+
+func TestTimeValueLayout(t *testing.T) {
+	since := TimeValue{Layout: "2006-01-02"}
+
+	ensureError(t, since.Set("2020-11-20"))
+	if got, want := since.String(), "2020-11-20"; got != want {
+		t.Errorf("GOT: %q; WANT: %q", got, want)
+	}
+}
@@ -0,0 +1,59 @@
+package tparse
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Normalize breaks the duration down into whole days of twenty-four hours, hours, minutes, seconds,
+// and the remaining nanoseconds, each carried into the next larger unit, so ninety minutes is one
+// hour and thirty minutes. For a negative duration, every component is zero or negative.
+func Normalize(d time.Duration) (days, hours, minutes, seconds, nanos int) {
+	days = int(d / (24 * time.Hour))
+	d %= 24 * time.Hour
+	hours = int(d / time.Hour)
+	d %= time.Hour
+	minutes = int(d / time.Minute)
+	d %= time.Minute
+	seconds = int(d / time.Second)
+	d %= time.Second
+	return days, hours, minutes, seconds, int(d)
+}
+
+// FormatDuration returns the duration as a string of terms with the largest units first, omitting
+// terms that are zero, such as "1h30m" for ninety minutes, and "1d2h" for twenty six hours. Fractions
+// of a second are written in milliseconds, microseconds, and nanoseconds. Every term of a negative
+// duration is signed, as in "-1h-30m", because each sign applies only to its own term, so that the
+// result is parsed back into the same duration by AddDuration and ParseDuration. Because those sum
+// terms as floating point numbers, this is exact to the nanosecond for durations up to about 104
+// days, and within a microsecond for longer ones. The terms of a duration within a microsecond of
+// the limits of time.Duration, such as math.MaxInt64 and math.MinInt64, sum past that limit, so
+// they fail to parse with a duration overflow error. A zero duration is written "0".
+func FormatDuration(d time.Duration) string {
+	if d == 0 {
+		return "0"
+	}
+	days, hours, minutes, seconds, nanos := Normalize(d)
+	terms := []struct {
+		number int
+		unit   string
+	}{
+		{days, "d"},
+		{hours, "h"},
+		{minutes, "m"},
+		{seconds, "s"},
+		{nanos / 1e6, "ms"},
+		{nanos / 1e3 % 1e3, "us"},
+		{nanos % 1e3, "ns"},
+	}
+	var b strings.Builder
+	for _, term := range terms {
+		if term.number == 0 {
+			continue
+		}
+		b.WriteString(strconv.Itoa(term.number))
+		b.WriteString(term.unit)
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package tparse
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	days, hours, minutes, seconds, nanos := Normalize(26*time.Hour + 90*time.Minute + 61*time.Second + 5)
+	if days != 1 || hours != 3 || minutes != 31 || seconds != 1 || nanos != 5 {
+		t.Errorf("GOT: %d %d %d %d %d; WANT: 1 3 31 1 5", days, hours, minutes, seconds, nanos)
+	}
+
+	days, hours, minutes, seconds, nanos = Normalize(-90 * time.Minute)
+	if days != 0 || hours != -1 || minutes != -30 || seconds != 0 || nanos != 0 {
+		t.Errorf("GOT: %d %d %d %d %d; WANT: 0 -1 -30 0 0", days, hours, minutes, seconds, nanos)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected string
+	}{
+		{"90m", "1h30m"},
+		{"3600s", "1h"},
+		{"26h", "1d2h"},
+		{"1.5s", "1s500ms"},
+		{"1001001ns", "1ms1us1ns"},
+		{"-90m", "-1h-30m"},
+		{"0s", "0"},
+	}
+
+	t.Run("extremes", func(t *testing.T) {
+		if actual, expected := FormatDuration(math.MinInt64), "-106751d-23h-47m-16s-854ms-775us-808ns"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		// Within a microsecond of the limits, the terms sum past them when parsed back.
+		for _, d := range []time.Duration{math.MaxInt64, math.MinInt64, math.MaxInt64 - 100, math.MinInt64 + 100} {
+			_, err := ParseDuration(FormatDuration(d))
+			ensureError(t, err, "duration overflow")
+		}
+		// Further from the limits, they are parsed back to within a microsecond.
+		for _, d := range []time.Duration{math.MaxInt64 - time.Microsecond, math.MinInt64 + time.Microsecond, math.MaxInt64 / 3} {
+			actual, err := ParseDuration(FormatDuration(d))
+			ensureError(t, err)
+			if diff := actual - d; diff < -time.Microsecond || diff > time.Microsecond {
+				t.Errorf("Actual: %d; Expected: within a microsecond of %d", actual, d)
+			}
+		}
+	})
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			d, err := ParseDuration(c.value)
+			ensureError(t, err)
+			if actual := FormatDuration(d); actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("reparse", func(t *testing.T) {
+		for _, d := range []time.Duration{
+			0,
+			1,
+			-1,
+			90 * time.Minute,
+			-(49*time.Hour + 59*time.Minute + 999999999),
+			100*24*time.Hour + 1,
+			-(100*24*time.Hour + 1),
+		} {
+			actual, err := ParseDuration(FormatDuration(d))
+			ensureError(t, err)
+			if actual != d {
+				t.Errorf("Formatted: %q; Actual: %d; Expected: %d", FormatDuration(d), actual, d)
+			}
+		}
+	})
+}
@@ -0,0 +1,29 @@
+//go:build go1.18
+// +build go1.18
+
+package tparse
+
+import (
+	"testing"
+	"time"
+)
+
+func FuzzAddDuration(f *testing.F) {
+	for _, seed := range []string{"", "+", "-", ".", "1.", ".5h", "now+", "1µs", "+1.5days-3.21hours", "3 days ago", "1e9s", "1h1h"} {
+		f.Add(seed)
+	}
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	f.Fuzz(func(t *testing.T, s string) {
+		actual, err := AddDuration(base, s)
+		if err != nil {
+			if actual != base {
+				t.Errorf("Actual: %s; Expected: %s", actual, base)
+			}
+			if _, ok := err.(*ParseError); !ok {
+				t.Errorf("GOT: %T; WANT: %T", err, &ParseError{})
+			}
+		}
+		_, _ = ParseNow(time.RFC3339, s)
+	})
+}
@@ -1,3 +1,3 @@
 module github.com/karrick/tparse/v2
 
-go 1.12
+go 1.13
@@ -0,0 +1,132 @@
+package tparse
+
+import (
+	"math"
+	"time"
+)
+
+// Offset is a relative amount of time parsed from a duration string, before its calendar
+// components have been resolved against a base time. Years, Months, and Days may be fractional;
+// Apply collapses the fractional part of each into the next smaller component.
+type Offset struct {
+	Years    float64
+	Months   float64
+	Days     float64
+	Duration time.Duration
+}
+
+// Apply adds the offset to base. Fractional years are collapsed into twelve months per year,
+// fractional months into thirty days per month, and fractional days into twenty-four hours per day,
+// before the whole years, months, and days are added using time.Time.AddDate, and the remaining
+// duration is added using time.Time.Add. Use Parser.Apply to collapse fractional years and months
+// using other factors.
+func (o Offset) Apply(base time.Time) time.Time {
+	return defaultParser.Apply(o, base)
+}
+
+// Add parses the duration string like Decompose, and returns the sum of the offset and the parsed
+// offset, so that adjustments may be accumulated one expression at a time. Because each sign applies
+// only to the term it precedes, the result is the same as decomposing the concatenation of the
+// expressions, such as "1h" and "-30m" as "1h-30m", except that a trailing "ago" negates only the
+// expression it ends. On error, the offset is returned unchanged.
+func (o Offset) Add(s string) (Offset, error) {
+	other, err := Decompose(s)
+	if err != nil {
+		return o, err
+	}
+	if d := other.Duration; d > 0 && o.Duration > math.MaxInt64-d || d < 0 && o.Duration < math.MinInt64-d {
+		return o, newParseError(s, s, errDurationOverflow)
+	}
+	sum := Offset{
+		Years:    o.Years + other.Years,
+		Months:   o.Months + other.Months,
+		Days:     o.Days + other.Days,
+		Duration: o.Duration + other.Duration,
+	}
+	if defaultParser.overflows(sum) {
+		return o, newParseError(s, s, errDurationOverflow)
+	}
+	return sum, nil
+}
+
+// Changed reports which fields of a time adding a duration string to it changed, such as whether
+// it moved to another day or only to another time of the same day.
+type Changed struct {
+	Years  bool
+	Months bool
+	Days   bool
+
+	// TimeOfDay reports a change in the wall clock time, to the nanosecond.
+	TimeOfDay bool
+}
+
+// changed returns which fields of t differ from those of base, as seen in the location of base.
+func changed(base, t time.Time) Changed {
+	t = t.In(base.Location())
+	by, bm, bd := base.Date()
+	ty, tm, td := t.Date()
+	bh, bmin, bs := base.Clock()
+	th, tmin, ts := t.Clock()
+	return Changed{
+		Years:     ty != by,
+		Months:    tm != bm,
+		Days:      td != bd,
+		TimeOfDay: th != bh || tmin != bmin || ts != bs || t.Nanosecond() != base.Nanosecond(),
+	}
+}
+
+// AddDurationDetailed is like AddDuration, but also reports which fields of the result differ from
+// those of base, for instance to tell whether a duration string moved the date or only the time of
+// day. So "+1mo" reports only Months, "+1d" reports only Days, and "+3h" from eleven in the evening
+// reports Days and TimeOfDay, because it crosses midnight. A field that changes and changes back,
+// as the month does for "+12mo", is not reported.
+func AddDurationDetailed(base time.Time, s string) (time.Time, Changed, error) {
+	return defaultParser.AddDurationDetailed(base, s)
+}
+
+// AddDurationDetailed is like the AddDurationDetailed function, but parses using the options of
+// the Parser.
+func (p *Parser) AddDurationDetailed(base time.Time, s string) (time.Time, Changed, error) {
+	o, err := p.Decompose(s)
+	if err != nil {
+		return base, Changed{}, err
+	}
+	t := p.Apply(o, base)
+	return t, changed(base, t), nil
+}
+
+// accumulator sums the terms of a duration by the category of their units.
+type accumulator struct {
+	years, months, days, duration float64
+}
+
+// add adds number of the specified unit to the totals, returning false when the unit is not
+// recognized.
+func (a *accumulator) add(number float64, unit string) bool {
+	if duration, ok := unitMap[unit]; ok {
+		a.duration += number * duration
+		return true
+	}
+	switch c, ok := calendarUnitMap[unit]; {
+	case !ok:
+		return false
+	case c == calendarMonth:
+		a.months += number
+	case c == calendarYear:
+		a.years += number
+	}
+	return true
+}
+
+// negate negates each of the totals.
+func (a *accumulator) negate() {
+	a.years = -a.years
+	a.months = -a.months
+	a.days = -a.days
+	a.duration = -a.duration
+}
+
+// offset returns the totals as an Offset.
+func (a accumulator) offset() Offset {
+	return Offset{Years: a.years, Months: a.months, Days: a.days, Duration: time.Duration(a.duration)}
+}
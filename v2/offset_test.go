@@ -0,0 +1,165 @@
+package tparse
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecompose(t *testing.T) {
+	o, err := Decompose("+1.5y-2mo3d4h")
+	ensureError(t, err)
+
+	expected := Offset{Years: 1.5, Months: -2, Duration: (3*24 + 4) * time.Hour}
+	if o != expected {
+		t.Errorf("Actual: %#v; Expected: %#v", o, expected)
+	}
+}
+
+func TestDecomposeApplyMatchesAddDuration(t *testing.T) {
+	bases := []time.Time{
+		time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+		time.Date(2020, time.February, 29, 12, 30, 0, 0, time.UTC),
+	}
+	values := []string{"", "1h", "+2.5years", "-2.5months", "+1.5days-3.21hours", "1d3w4mo-7y6h4m", "3 days ago"}
+
+	for _, base := range bases {
+		for _, value := range values {
+			expected, err := AddDuration(base, value)
+			ensureError(t, err)
+
+			o, err := Decompose(value)
+			ensureError(t, err)
+
+			if actual := o.Apply(base); actual != expected {
+				t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+			}
+		}
+	}
+}
+
+func TestDecomposeError(t *testing.T) {
+	o, err := Decompose("1h+xq")
+	ensureError(t, err, `unknown unit "xq"`)
+	if o != (Offset{}) {
+		t.Errorf("Actual: %#v; Expected: %#v", o, Offset{})
+	}
+}
+
+func TestOffsetAdd(t *testing.T) {
+	cases := [][]string{
+		{"1h", "30m"},
+		{"1h", "-30m"},
+		{"1y", "2mo", "3d"},
+		{"+1.5days", "-3.21hours", "1w"},
+		{"", "1h"},
+	}
+	for _, exprs := range cases {
+		concatenated := strings.Join(exprs, "")
+		t.Run(concatenated, func(t *testing.T) {
+			var actual Offset
+			for _, expr := range exprs {
+				var err error
+				actual, err = actual.Add(expr)
+				ensureError(t, err)
+			}
+			expected, err := Decompose(concatenated)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %#v; Expected: %#v", actual, expected)
+			}
+		})
+	}
+
+	t.Run("ago", func(t *testing.T) {
+		o, err := Offset{Duration: time.Hour}.Add("30m ago")
+		ensureError(t, err)
+		if expected := (Offset{Duration: 30 * time.Minute}); o != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", o, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		base := Offset{Days: 1}
+		o, err := base.Add("1h+xq")
+		ensureError(t, err, `unknown unit "xq"`)
+		if o != base {
+			t.Errorf("Actual: %#v; Expected: %#v", o, base)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		base := Offset{Duration: math.MaxInt64 - time.Hour}
+		o, err := base.Add("2h")
+		ensureError(t, err, "duration overflow")
+		if o != base {
+			t.Errorf("Actual: %#v; Expected: %#v", o, base)
+		}
+	})
+}
+
+func TestAddDurationDetailed(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected Changed
+	}{
+		{"", Changed{}},
+		{"+1mo", Changed{Months: true}},
+		{"+12mo", Changed{Years: true}},
+		{"+1d", Changed{Days: true}},
+		{"+24h", Changed{Days: true}},
+		{"+30m", Changed{TimeOfDay: true}},
+		{"+3h", Changed{Days: true, TimeOfDay: true}},
+		{"+1y2h", Changed{Years: true, Days: true, TimeOfDay: true}},
+		{"+1h-60m", Changed{}},
+		{"+1ns", Changed{TimeOfDay: true}},
+		{"1y2mo3d4h ago", Changed{Years: true, Months: true, Days: true, TimeOfDay: true}},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, changed, err := AddDurationDetailed(base, c.value)
+			ensureError(t, err)
+			if changed != c.expected {
+				t.Errorf("Actual: %#v; Expected: %#v", changed, c.expected)
+			}
+			expected, err := AddDuration(base, c.value)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+
+	t.Run("calendar days", func(t *testing.T) {
+		p := Parser{Calendar: true}
+		actual, changed, err := p.AddDurationDetailed(base, "+1w")
+		ensureError(t, err)
+		if expected := (Changed{Days: true}); changed != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", changed, expected)
+		}
+		if expected := base.AddDate(0, 0, 7); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("location of base", func(t *testing.T) {
+		// 23:00 at UTC-5 is the next day in UTC, but not in the location of base.
+		loc := time.FixedZone("EST", -5*3600)
+		_, changed, err := AddDurationDetailed(time.Date(2009, time.November, 10, 18, 0, 0, 0, loc), "+5h")
+		ensureError(t, err)
+		if expected := (Changed{TimeOfDay: true}); changed != expected {
+			t.Errorf("Actual: %#v; Expected: %#v", changed, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		actual, changed, err := AddDurationDetailed(base, "+1mo+1x")
+		ensureError(t, err, `unknown unit "x"`)
+		if actual != base || changed != (Changed{}) {
+			t.Errorf("Actual: %s, %#v; Expected: %s, %#v", actual, changed, base, Changed{})
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package tparse
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// defaultParser is used by the package level functions.
+var defaultParser Parser
+
+// Parser parses durations and times using configurable conventions. The zero value is ready to use
+// and parses exactly like the package level functions.
+type Parser struct {
+	// Layout is the layout used by Parse to parse values that are neither relative to a key in Dict
+	// nor epoch values.
+	Layout string
+
+	// Dict maps strings to the times they represent for Parse, as the dict argument of ParseWithMap
+	// does.
+	Dict map[string]time.Time
+
+	// Keywords maps strings to functions that resolve them to a time each time they are parsed, such
+	// as "now" to time.Now. Like keys in Dict, the longest keyword matching the start of a value is
+	// used, and the remainder of the value is parsed as a duration. Keywords are checked before Dict
+	// and epoch values, so a keyword takes precedence over a key in Dict, even a longer one. When
+	// "now" is a keyword, the abbreviations "bod", "bow", "bom", and "boy", and "eod", "eow", "eom",
+	// and "eoy", resolve to the beginning and end of the day, week, month, and year of its time, as
+	// they do for ParseNow, but checked after keys in Dict.
+	Keywords map[string]func() time.Time
+
+	// StripMonotonic causes Parse to strip the monotonic clock reading from the times it returns,
+	// as time.Time.Round(0) does. Times resolved relative to time.Now, such as those of the "now"
+	// keyword, carry a monotonic clock reading, which time.Time.Sub and time.Time.Before prefer
+	// over the wall clock. That is useful for measuring within a process, but surprising when the
+	// time is compared with one that has been serialized, which never has such a reading. When
+	// false, any monotonic clock reading is retained.
+	StripMonotonic bool
+
+	// OutputLocation, when not nil, is the location to which Parse and ParseWithMap convert the
+	// times they return, using time.Time.In, so that results are in a fixed reporting zone
+	// regardless of the zone of the input. Conversion does not change the instant a time represents.
+	// When nil, times are returned in the location in which they were parsed.
+	OutputLocation *time.Location
+
+	// NowGranularity is the granularity to which the time of the "now" keyword is truncated, using
+	// time.Time.Truncate, before any duration following it is applied. For instance, with a
+	// granularity of time.Minute, every "now-1h" parsed within the same minute resolves to the same
+	// time, which makes the results suitable as cache keys. When zero or negative, the time is not
+	// truncated.
+	NowGranularity time.Duration
+
+	// MonthsPerYear is the number of months a fractional year is collapsed into. Whole years are
+	// always added using time.Time.AddDate. When zero, twelve months per year are used.
+	MonthsPerYear float64
+
+	// DaysPerMonth is the number of days a fractional month is collapsed into, for instance 30 for
+	// the 30/360 day count convention. Whole months are always added using time.Time.AddDate. When
+	// zero, thirty days per month are used.
+	DaysPerMonth float64
+
+	// Strict causes parsing to fail when the same unit appears more than once in a duration, even
+	// when spelled differently, as in "1h1h" or "1h30m1hour", which often indicates a typo. When
+	// false, repeated units are summed.
+	Strict bool
+
+	// Calendar causes days and weeks to be added as calendar days using time.Time.AddDate, like
+	// months and years, rather than as a fixed twenty-four hours per day. Calendar days preserve
+	// the wall clock time across daylight saving time transitions, so "+1w" from noon is noon on
+	// the same weekday. When false, days and weeks are fixed durations.
+	Calendar bool
+
+	// Round is the granularity to which the duration portion of an offset is rounded, after
+	// fractional years, months, and days have been collapsed into it, and before it is added. For
+	// instance, time.Second ensures "+2.5days" lands on an exact second boundary, free of any
+	// floating point noise. When zero or negative, the duration is not rounded.
+	Round time.Duration
+
+	// DurationUnits maps additional unit tokens to their fixed length, for instance to recognize
+	// localized tokens such as "Tag" for 24 hours. These take precedence over built-in units of the
+	// same name.
+	DurationUnits map[string]time.Duration
+
+	// MonthUnits maps additional unit tokens to the number of calendar months they span, for
+	// instance "Monat" to 1 or "Jahr" to 12. Like the built-in month and year units, these are added
+	// using time.Time.AddDate. These take precedence over DurationUnits and built-in units of the
+	// same name.
+	MonthUnits map[string]int
+
+	// UnknownUnit, when not nil, is called with each unit that is neither configured on the Parser
+	// nor built in, along with its signed number, so that units may be resolved at run time. It
+	// returns the duration, and the years, months, and days that number of the unit spans, and true;
+	// or false when it does not recognize the unit either, in which case parsing fails as usual.
+	UnknownUnit func(unit string, number float64) (duration time.Duration, years, months, days int, ok bool)
+
+	// WeekStart is the day on which weeks begin for the week anchors of AddDurationFrom, such as
+	// "start-of-week" and "eow", and the "bow" and "eow" anchors of Parse, for instance Sunday in
+	// locales that begin weeks on Sunday. It is a pointer so that the zero Parser may begin weeks on
+	// Monday, as ISO 8601 and the package level functions do, which it does when WeekStart is nil.
+	WeekStart *time.Weekday
+
+	// LayoutFirst causes a value that is both a valid epoch value and valid for a non-empty Layout
+	// to be parsed using the Layout, so that with a layout such as "20060102", the value "20201225"
+	// is Christmas of 2020 rather than an epoch value in 1970. When false, epoch values take
+	// precedence over the Layout. An epoch value prefixed by '@' is always an epoch value.
+	LayoutFirst bool
+
+	// Natural causes durations to accept spelled out words found in transcribed speech: "half"
+	// and "quarter" in place of a number, optionally followed by "a" or "an", so that "half an
+	// hour" is thirty minutes and "quarter day" is six hours. Each word must be followed by
+	// whitespace, so "halfh" is an unknown unit rather than half an hour. Natural also accepts a
+	// hyphen between a number and its unit, as in "30-minutes", as long as a letter immediately
+	// follows it. A hyphen after a unit still negates the term that follows it, so "1h-30m" is
+	// thirty minutes. When false, these words and hyphens are not recognized.
+	Natural bool
+
+	// OnParse, when not nil, is called after each call to Parse or ParseWithMap, whether or not it
+	// succeeded, with the path that resolved the value and how long parsing took, for instance to
+	// export metrics. The path is "now" for the "now" keyword and the calendar anchors resolved
+	// relative to it, "keyword" for other Keywords, "dict" for keys in Dict or the extra map, "epoch"
+	// for the "epoch" keyword and epoch values, and "layout" for values parsed using Layout. When
+	// nil, parsing is not timed.
+	OnParse func(path string, d time.Duration)
+}
+
+// NewParser returns a Parser that parses values using the specified layout and dict, so that they
+// need not be repeated at every call site. The returned Parser has the keyword "now" registered,
+// which resolves to the current time, and more may be added to its Keywords.
+//
+//	p := tparse.NewParser(time.RFC3339, map[string]time.Time{"start": start})
+//	p.Keywords["deploy"] = deployTime
+//	t, err := p.Parse("start+1h")
+func NewParser(layout string, dict map[string]time.Time) *Parser {
+	return &Parser{
+		Layout:   layout,
+		Dict:     dict,
+		Keywords: map[string]func() time.Time{"now": time.Now},
+	}
+}
+
+// Parse is like ParseWithMap, using the Layout and Dict of the Parser.
+func (p *Parser) Parse(value string) (time.Time, error) {
+	return p.ParseWithMap(value, nil)
+}
+
+// ParseWithMap is like Parse, but uses the keys of extra in addition to those of the Dict of the
+// Parser, with those of extra taking precedence when both have the same key. The longest key
+// matching the start of value is used, regardless of which of the two maps it is in.
+func (p *Parser) ParseWithMap(value string, extra map[string]time.Time) (time.Time, error) {
+	dict := p.Dict
+	if len(extra) > 0 {
+		dict = make(map[string]time.Time, len(p.Dict)+len(extra))
+		for k, v := range p.Dict {
+			dict[k] = v
+		}
+		for k, v := range extra {
+			dict[k] = v
+		}
+	}
+	var x *Explanation
+	var start time.Time
+	if p.OnParse != nil {
+		x = new(Explanation)
+		start = time.Now()
+	}
+	t, err := p.parseWithMap(p.Layout, value, dict, nil, true, x)
+	if p.StripMonotonic {
+		t = t.Round(0)
+	}
+	if p.OutputLocation != nil && err == nil {
+		t = t.In(p.OutputLocation)
+	}
+	if p.OnParse != nil {
+		p.OnParse(p.path(value, x.Anchor, dict), time.Since(start))
+	}
+	return t, err
+}
+
+// keywordTime returns the time the keyword resolves to, truncated to NowGranularity for the "now"
+// keyword.
+func (p *Parser) keywordTime(keyword string) time.Time {
+	t := p.Keywords[keyword]()
+	if keyword == "now" && p.NowGranularity > 0 {
+		t = t.Truncate(p.NowGranularity)
+	}
+	return t
+}
+
+// path returns the label OnParse reports for value resolved relative to anchor. Keywords are
+// matched before keys in dict, which are matched before the "epoch" keyword, epoch values, and the
+// layout, so a value starting with an anchor that is both a keyword and a key was resolved as the
+// keyword. A value not starting with its anchor, such as an epoch value, was resolved by neither.
+func (p *Parser) path(value, anchor string, dict map[string]time.Time) string {
+	if !strings.HasPrefix(value, anchor) {
+		return anchor
+	}
+	if _, ok := p.Keywords[anchor]; ok {
+		if anchor == "now" {
+			return "now"
+		}
+		return "keyword"
+	}
+	if _, ok := dict[anchor]; ok {
+		return "dict"
+	}
+	if _, ok := calendarAnchors[anchor]; ok {
+		return "now"
+	}
+	return anchor
+}
+
+func (p *Parser) monthsPerYear() float64 {
+	if p.MonthsPerYear != 0 {
+		return p.MonthsPerYear
+	}
+	return 12
+}
+
+func (p *Parser) daysPerMonth() float64 {
+	if p.DaysPerMonth != 0 {
+		return p.DaysPerMonth
+	}
+	return 30
+}
+
+// add adds number of the specified unit to the totals, preferring units configured on the Parser
+// over built-in units, and returns false when the unit is not recognized.
+func (p *Parser) add(a *accumulator, number float64, unit string) bool {
+	if months, ok := p.MonthUnits[unit]; ok {
+		a.months += number * float64(months)
+		return true
+	}
+	if duration, ok := p.DurationUnits[unit]; ok {
+		a.duration += number * float64(duration)
+		return true
+	}
+	if p.Calendar {
+		switch unitMap[unit] {
+		case float64(24 * time.Hour):
+			a.days += number
+			return true
+		case float64(7 * 24 * time.Hour):
+			a.days += 7 * number
+			return true
+		}
+	}
+	if a.add(number, unit) {
+		return true
+	}
+	if p.UnknownUnit == nil || unit == "" {
+		return false
+	}
+	duration, years, months, days, ok := p.UnknownUnit(unit, number)
+	if ok {
+		a.duration += float64(duration)
+		a.years += float64(years)
+		a.months += float64(months)
+		a.days += float64(days)
+	}
+	return ok
+}
+
+// AddDuration is like the AddDuration function, but parses using the options of the Parser, and
+// collapses fractional years and months using its conventions.
+func (p *Parser) AddDuration(base time.Time, s string) (time.Time, error) {
+	o, err := p.Decompose(s)
+	if err != nil {
+		return base, err
+	}
+	return p.Apply(o, base), nil
+}
+
+// Apply adds the offset to base. Fractional years are collapsed into MonthsPerYear months per year,
+// fractional months into DaysPerMonth days per month, and fractional days into twenty-four hours
+// per day, before the whole years, months, and days are added using time.Time.AddDate, and the
+// remaining duration, rounded to Round, is added using time.Time.Add.
+func (p *Parser) Apply(o Offset, base time.Time) time.Time {
+	totalYears, totalMonths, totalDays, totalDuration := p.collapse(o)
+	if totalYears != 0 || totalMonths != 0 || totalDays != 0 {
+		base = base.AddDate(int(totalYears), int(totalMonths), int(totalDays))
+	}
+	if totalDuration != 0 {
+		base = base.Add(time.Duration(totalDuration).Round(p.Round))
+	}
+	return base
+}
+
+// collapse returns the whole years, months, and days of the offset, and its duration in
+// nanoseconds, with the fractional years, months, and days collapsed into it as Apply describes.
+// Collapsing does not depend on any base time, so an offset whose duration overflows
+// time.Duration once collapsed may be rejected before it is applied.
+func (p *Parser) collapse(o Offset) (totalYears, totalMonths, totalDays, totalDuration float64) {
+	totalYears, totalMonths, totalDays, totalDuration = o.Years, o.Months, o.Days, float64(o.Duration)
+	if totalYears != 0 {
+		whole := math.Trunc(totalYears)
+		fraction := totalYears - whole
+		totalYears = whole
+		totalMonths += p.monthsPerYear() * fraction
+	}
+	if totalMonths != 0 {
+		whole := math.Trunc(totalMonths)
+		fraction := totalMonths - whole
+		totalMonths = whole
+		totalDays += p.daysPerMonth() * fraction
+	}
+	if totalDays != 0 {
+		whole := math.Trunc(totalDays)
+		fraction := totalDays - whole
+		totalDays = whole
+		totalDuration += (fraction * 24.0 * float64(time.Hour))
+	}
+	return totalYears, totalMonths, totalDays, totalDuration
+}
+
+// overflows returns true when the duration of the offset, once its fractional years, months, and
+// days are collapsed into it, exceeds the range of time.Duration.
+func (p *Parser) overflows(o Offset) bool {
+	_, _, _, duration := p.collapse(o)
+	return math.Abs(duration) >= math.MaxInt64
+}
@@ -0,0 +1,795 @@
+package tparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParserParse(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	p := NewParser(time.RFC3339, map[string]time.Time{"start": start})
+
+	t.Run("layout", func(t *testing.T) {
+		actual, err := p.Parse(rfc3339)
+		ensureError(t, err)
+		if expected := time.Unix(1136214245, 0); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("epoch", func(t *testing.T) {
+		actual, err := p.Parse("1136214245")
+		ensureError(t, err)
+		if expected := time.Unix(1136214245, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("dict", func(t *testing.T) {
+		actual, err := p.Parse("start+1h")
+		ensureError(t, err)
+		if expected := start.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("dict uses parser options", func(t *testing.T) {
+		p := NewParser(time.RFC3339, map[string]time.Time{"start": start})
+		p.DurationUnits = map[string]time.Duration{"Tag": 24 * time.Hour}
+		actual, err := p.Parse("start+1Tag")
+		ensureError(t, err)
+		if expected := start.Add(24 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := p.Parse("not a time")
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParserParseWithMap(t *testing.T) {
+	deploy := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	release := deploy.AddDate(0, 0, 7)
+	p := NewParser(time.RFC3339, map[string]time.Time{"deploy": deploy, "release": release})
+
+	t.Run("disjoint", func(t *testing.T) {
+		request := deploy.AddDate(0, 1, 0)
+		extra := map[string]time.Time{"request": request}
+		for value, expected := range map[string]time.Time{
+			"deploy+1h":  deploy.Add(time.Hour),
+			"release":    release,
+			"request-1h": request.Add(-time.Hour),
+		} {
+			actual, err := p.ParseWithMap(value, extra)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+			}
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		override := deploy.AddDate(1, 0, 0)
+		actual, err := p.ParseWithMap("deploy+1h", map[string]time.Time{"deploy": override})
+		ensureError(t, err)
+		if expected := override.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		// the default dict is not modified
+		actual, err = p.Parse("deploy")
+		ensureError(t, err)
+		if actual != deploy {
+			t.Errorf("Actual: %s; Expected: %s", actual, deploy)
+		}
+	})
+
+	t.Run("longest across union", func(t *testing.T) {
+		staging := deploy.AddDate(0, 2, 0)
+		actual, err := p.ParseWithMap("deploy_staging+1h", map[string]time.Time{"deploy_staging": staging})
+		ensureError(t, err)
+		if expected := staging.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserKeywords(t *testing.T) {
+	deploy := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	p := NewParser(time.RFC3339, map[string]time.Time{"deploy": deploy.AddDate(1, 0, 0)})
+	p.Keywords["deploy_time"] = func() time.Time { return deploy }
+
+	t.Run("custom", func(t *testing.T) {
+		actual, err := p.Parse("deploy_time+1h")
+		ensureError(t, err)
+		if expected := deploy.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("now", func(t *testing.T) {
+		before := time.Now().Add(-time.Hour)
+		actual, err := p.Parse("now-1h")
+		ensureError(t, err)
+		after := time.Now().Add(-time.Hour)
+		if before.After(actual) || actual.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+		}
+	})
+
+	t.Run("before dict", func(t *testing.T) {
+		p := NewParser(time.RFC3339, map[string]time.Time{"deploy_time": deploy.AddDate(1, 0, 0)})
+		p.Keywords["deploy"] = func() time.Time { return deploy }
+		actual, err := p.Parse("deploy_time")
+		ensureError(t, err, `unknown unit "_time"`)
+		if actual != deploy {
+			t.Errorf("Actual: %s; Expected: %s", actual, deploy)
+		}
+	})
+
+	t.Run("longest", func(t *testing.T) {
+		actual, err := p.Parse("deploy_time")
+		ensureError(t, err)
+		if actual != deploy {
+			t.Errorf("Actual: %s; Expected: %s", actual, deploy)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := p.Parse("deploy_time+1x")
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 13 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 13)
+		}
+	})
+}
+
+func TestParserNowGranularity(t *testing.T) {
+	clock := []time.Time{
+		time.Date(2009, time.November, 10, 23, 0, 5, 0, time.UTC),
+		time.Date(2009, time.November, 10, 23, 0, 55, 999999999, time.UTC),
+	}
+	newParser := func() *Parser {
+		p := NewParser(time.RFC3339, nil)
+		var calls int
+		p.Keywords["now"] = func() time.Time {
+			now := clock[calls%len(clock)]
+			calls++
+			return now
+		}
+		return p
+	}
+
+	t.Run("default", func(t *testing.T) {
+		p := newParser()
+		first, err := p.Parse("now-1h")
+		ensureError(t, err)
+		second, err := p.Parse("now-1h")
+		ensureError(t, err)
+		if first == second {
+			t.Errorf("Actual: %s; Expected: different times", first)
+		}
+	})
+
+	t.Run("minute", func(t *testing.T) {
+		p := newParser()
+		p.NowGranularity = time.Minute
+		first, err := p.Parse("now-1h")
+		ensureError(t, err)
+		second, err := p.Parse("now-1h")
+		ensureError(t, err)
+		if first != second {
+			t.Errorf("Actual: %s and %s; Expected: identical times", first, second)
+		}
+		if expected := time.Date(2009, time.November, 10, 22, 0, 0, 0, time.UTC); first != expected {
+			t.Errorf("Actual: %s; Expected: %s", first, expected)
+		}
+	})
+
+	t.Run("other keywords", func(t *testing.T) {
+		p := newParser()
+		p.NowGranularity = time.Minute
+		p.Keywords["then"] = func() time.Time { return clock[0] }
+		actual, err := p.Parse("then")
+		ensureError(t, err)
+		if actual != clock[0] {
+			t.Errorf("Actual: %s; Expected: %s", actual, clock[0])
+		}
+	})
+}
+
+func TestParserStripMonotonic(t *testing.T) {
+	// The String method of a time with a monotonic clock reading reports it as "m=".
+	hasMonotonic := func(t time.Time) bool { return strings.Contains(t.String(), " m=") }
+
+	t.Run("retained by default", func(t *testing.T) {
+		p := NewParser(time.RFC3339, nil)
+		actual, err := p.Parse("now+1h")
+		ensureError(t, err)
+		if !hasMonotonic(actual) {
+			t.Errorf("Actual: %s; Expected: monotonic clock reading", actual)
+		}
+	})
+
+	t.Run("stripped", func(t *testing.T) {
+		p := NewParser(time.RFC3339, nil)
+		p.StripMonotonic = true
+		actual, err := p.Parse("now+1h")
+		ensureError(t, err)
+		if hasMonotonic(actual) {
+			t.Errorf("Actual: %s; Expected: no monotonic clock reading", actual)
+		}
+		if actual != actual.Round(0) {
+			t.Errorf("Actual: %#v; Expected: %#v", actual, actual.Round(0))
+		}
+	})
+}
+
+func TestParserDaysPerMonth(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("default", func(t *testing.T) {
+		var p Parser
+		actual, err := p.AddDuration(base, "+0.5mo")
+		ensureError(t, err)
+		if expected := base.AddDate(0, 0, 15); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("fractional month", func(t *testing.T) {
+		p := Parser{DaysPerMonth: 31}
+		actual, err := p.AddDuration(base, "+0.5mo")
+		ensureError(t, err)
+		if expected := base.AddDate(0, 0, 15).Add(12 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("whole month", func(t *testing.T) {
+		p := Parser{DaysPerMonth: 31}
+		actual, err := p.AddDuration(base, "+1mo")
+		ensureError(t, err)
+		if expected := base.AddDate(0, 1, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserMonthsPerYear(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("fractional year", func(t *testing.T) {
+		p := Parser{MonthsPerYear: 13}
+		actual, err := p.AddDuration(base, "+0.5y")
+		ensureError(t, err)
+		// 6.5 months is six months and fifteen days
+		if expected := base.AddDate(0, 6, 15); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("whole year", func(t *testing.T) {
+		p := Parser{MonthsPerYear: 13}
+		actual, err := p.AddDuration(base, "+2y")
+		ensureError(t, err)
+		if expected := base.AddDate(2, 0, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserStrict(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("default sums", func(t *testing.T) {
+		var p Parser
+		actual, err := p.AddDuration(base, "1h1h")
+		ensureError(t, err)
+		if expected := base.Add(2 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		p := Parser{Strict: true}
+		_, err := p.AddDuration(base, "1h1h")
+		ensureError(t, err, `duplicate unit "h"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 3 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 3)
+		}
+	})
+
+	t.Run("strict different spelling", func(t *testing.T) {
+		p := Parser{Strict: true}
+		_, err := p.AddDuration(base, "1h30m2hours")
+		ensureError(t, err, `duplicate unit "hours"`)
+	})
+
+	t.Run("strict distinct units", func(t *testing.T) {
+		p := Parser{Strict: true}
+		actual, err := p.AddDuration(base, "1y2mo3d4h5m6s")
+		ensureError(t, err)
+		if expected := base.AddDate(1, 2, 0).Add(76*time.Hour + 5*time.Minute + 6*time.Second); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserRound(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("default", func(t *testing.T) {
+		var p Parser
+		actual, err := p.AddDuration(base, "+1.0000001d")
+		ensureError(t, err)
+		if got := actual.Sub(base) % time.Second; got == 0 {
+			t.Errorf("GOT: %v; WANT: sub-second remainder", got)
+		}
+	})
+
+	t.Run("seconds", func(t *testing.T) {
+		p := Parser{Round: time.Second}
+		for _, value := range []string{"+2.5days", "+1.0000001d", "+0.3333333d"} {
+			actual, err := p.AddDuration(base, value)
+			ensureError(t, err)
+			if got := actual.Sub(base) % time.Second; got != 0 {
+				t.Errorf("%s: GOT: %v; WANT: %v", value, got, time.Duration(0))
+			}
+		}
+		actual, err := p.AddDuration(base, "+2.5days")
+		ensureError(t, err)
+		if expected := base.Add(60 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("milliseconds", func(t *testing.T) {
+		p := Parser{Round: time.Millisecond}
+		actual, err := p.AddDuration(base, "+1.0000001d")
+		ensureError(t, err)
+		if expected := base.Add(24*time.Hour + 9*time.Millisecond); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserUnits(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	p := Parser{
+		DurationUnits: map[string]time.Duration{"Tag": 24 * time.Hour, "Wochen": 168 * time.Hour, "m": time.Millisecond},
+		MonthUnits:    map[string]int{"Monat": 1, "Jahr": 12},
+	}
+
+	t.Run("duration units", func(t *testing.T) {
+		actual, err := p.AddDuration(base, "1Tag2Wochen")
+		ensureError(t, err)
+		if expected := base.Add(15 * 24 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("month units", func(t *testing.T) {
+		actual, err := p.AddDuration(base, "1Jahr 2Monat")
+		ensureError(t, err)
+		if expected := base.AddDate(1, 2, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("built-in units remain", func(t *testing.T) {
+		actual, err := p.AddDuration(base, "1Tag3h")
+		ensureError(t, err)
+		if expected := base.Add(27 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("override built-in unit", func(t *testing.T) {
+		actual, err := p.AddDuration(base, "5m")
+		ensureError(t, err)
+		if expected := base.Add(5 * time.Millisecond); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("strict compares canonical units", func(t *testing.T) {
+		p := Parser{Strict: true, DurationUnits: p.DurationUnits, MonthUnits: p.MonthUnits}
+		_, err := p.AddDuration(base, "1Tag1d")
+		ensureError(t, err, `duplicate unit "d"`)
+		_, err = p.AddDuration(base, "1Jahr1y")
+		ensureError(t, err, `duplicate unit "y"`)
+	})
+}
+
+func TestParserCalendar(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	ensureError(t, err)
+	// daylight saving time begins on March 8, 2020
+	base := time.Date(2020, time.March, 5, 12, 0, 0, 0, loc)
+
+	t.Run("default", func(t *testing.T) {
+		var p Parser
+		actual, err := p.AddDuration(base, "+1w")
+		ensureError(t, err)
+		if expected := base.Add(7 * 24 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		if actual.Hour() != 13 {
+			t.Errorf("Actual: %d; Expected: %d", actual.Hour(), 13)
+		}
+	})
+
+	for _, value := range []string{"+1w", "+1week", "+7d", "+7days"} {
+		t.Run(value, func(t *testing.T) {
+			p := Parser{Calendar: true}
+			actual, err := p.AddDuration(base, value)
+			ensureError(t, err)
+			if expected := time.Date(2020, time.March, 12, 12, 0, 0, 0, loc); !actual.Equal(expected) {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+
+	t.Run("fractional", func(t *testing.T) {
+		p := Parser{Calendar: true}
+		actual, err := p.AddDuration(base, "+3.5d")
+		ensureError(t, err)
+		if expected := time.Date(2020, time.March, 9, 0, 0, 0, 0, loc); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserUnknownUnit(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	var calls []string
+	p := Parser{
+		UnknownUnit: func(unit string, number float64) (time.Duration, int, int, int, bool) {
+			calls = append(calls, unit)
+			switch unit {
+			case "sprint", "sprints":
+				return 0, 0, 0, int(14 * number), true
+			case "quarter":
+				return 0, 0, int(3 * number), 0, true
+			case "shift":
+				return time.Duration(number * float64(8*time.Hour)), 0, 0, 0, true
+			}
+			return 0, 0, 0, 0, false
+		},
+	}
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"1sprint", base.AddDate(0, 0, 14)},
+		{"-2sprints", base.AddDate(0, 0, -28)},
+		{"1quarter", base.AddDate(0, 3, 0)},
+		{"1.5shift", base.Add(12 * time.Hour)},
+		{"1sprint2h", base.AddDate(0, 0, 14).Add(2 * time.Hour)},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := p.AddDuration(base, c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("built-in units first", func(t *testing.T) {
+		calls = nil
+		_, err := p.AddDuration(base, "1h")
+		ensureError(t, err)
+		if len(calls) != 0 {
+			t.Errorf("GOT: %v; WANT: no calls", calls)
+		}
+	})
+
+	t.Run("not recognized", func(t *testing.T) {
+		_, err := p.AddDuration(base, "1dya")
+		ensureError(t, err, `unknown unit "dya"; did you mean "day"?`)
+	})
+
+	t.Run("missing unit", func(t *testing.T) {
+		_, err := p.AddDuration(base, "1h5")
+		ensureError(t, err, `missing unit after number "5"`)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		p := Parser{Strict: true, UnknownUnit: p.UnknownUnit}
+		_, err := p.AddDuration(base, "1sprint1mo")
+		ensureError(t, err)
+		_, err = p.AddDuration(base, "1sprint1sprint")
+		ensureError(t, err, `duplicate unit "sprint"`)
+	})
+}
+
+func TestParserLayoutFirst(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		p := Parser{Layout: "20060102"}
+		actual, err := p.Parse("20201225")
+		ensureError(t, err)
+		if expected := time.Unix(20201225, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout first", func(t *testing.T) {
+		p := Parser{Layout: "20060102", LayoutFirst: true}
+		actual, err := p.Parse("20201225")
+		ensureError(t, err)
+		if expected := time.Date(2020, time.December, 25, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("epoch not matching layout", func(t *testing.T) {
+		p := Parser{Layout: "20060102", LayoutFirst: true}
+		for value, expected := range map[string]time.Time{
+			"1609459200": time.Unix(1609459200, 0),
+			"@20201225":  time.Unix(20201225, 0),
+		} {
+			actual, err := p.Parse(value)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("%s: Actual: %s; Expected: %s", value, actual, expected)
+			}
+		}
+	})
+
+	t.Run("empty layout", func(t *testing.T) {
+		p := Parser{LayoutFirst: true}
+		actual, err := p.Parse("20201225")
+		ensureError(t, err)
+		if expected := time.Unix(20201225, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParserNatural(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	p := Parser{Natural: true}
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"half hour", base.Add(30 * time.Minute)},
+		{"half an hour", base.Add(30 * time.Minute)},
+		{"quarter day", base.Add(6 * time.Hour)},
+		{"a quarter hour", time.Time{}},
+		{"quarter of an hour", time.Time{}},
+		{"-half hour", base.Add(-30 * time.Minute)},
+		{"minus half an hour", base.Add(-30 * time.Minute)},
+		{"1 hour and a half", time.Time{}},
+		{"2 hours and half an hour", base.Add(150 * time.Minute)},
+		{"half hour ago", base.Add(-30 * time.Minute)},
+		{"1.5h", base.Add(90 * time.Minute)},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := p.AddDuration(base, c.value)
+			if c.expected.IsZero() {
+				if err == nil {
+					t.Errorf("GOT: %s; WANT: error", actual)
+				}
+				return
+			}
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("hyphen", func(t *testing.T) {
+		cases := []struct {
+			value    string
+			expected time.Time
+		}{
+			{"1-hour", base.Add(time.Hour)},
+			{"30-minutes", base.Add(30 * time.Minute)},
+			{"1-hour 30-minutes", base.Add(90 * time.Minute)},
+			{"2-days ago", base.Add(-48 * time.Hour)},
+			{"1.5-hours", base.Add(90 * time.Minute)},
+			{"1-hour-30m", base.Add(30 * time.Minute)},
+			{"1h-30m", base.Add(30 * time.Minute)},
+			{"1h -30m", base.Add(30 * time.Minute)},
+			{"-1-hour", base.Add(-time.Hour)},
+		}
+		for _, c := range cases {
+			actual, err := p.AddDuration(base, c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("%s: Actual: %s; Expected: %s", c.value, actual, c.expected)
+			}
+		}
+
+		_, err := p.AddDuration(base, "1-30m")
+		ensureError(t, err, `missing unit after number "1"`)
+		_, err = p.AddDuration(base, "1 -hour")
+		ensureError(t, err, `missing unit after number "1"`)
+		var q Parser
+		_, err = q.AddDuration(base, "1-hour")
+		ensureError(t, err, `missing unit after number "1"`)
+	})
+
+	t.Run("without space", func(t *testing.T) {
+		_, err := p.AddDuration(base, "halfh")
+		ensureError(t, err, `unknown unit "halfh"`)
+	})
+
+	t.Run("missing unit", func(t *testing.T) {
+		_, err := p.AddDuration(base, "half an ")
+		ensureError(t, err, `missing unit after number "half an"`)
+	})
+
+	t.Run("not natural", func(t *testing.T) {
+		var p Parser
+		_, err := p.AddDuration(base, "half hour")
+		ensureError(t, err, `unknown unit "half"`)
+	})
+}
+
+func TestParserOutputLocation(t *testing.T) {
+	loc := time.FixedZone("EST", -5*3600)
+	expected := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	p := NewParser(time.RFC3339, map[string]time.Time{"start": expected})
+	p.OutputLocation = loc
+
+	for _, value := range []string{"1257894000", "2009-11-10T23:00:00Z", "2009-11-11T08:00:00+09:00", "start", "now"} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := p.Parse(value)
+			ensureError(t, err)
+			if actual.Location() != loc {
+				t.Errorf("Actual: %s; Expected: %s", actual.Location(), loc)
+			}
+			if value != "now" && !actual.Equal(expected) {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		p := Parser{Layout: time.RFC3339}
+		actual, err := p.Parse("2009-11-11T08:00:00+09:00")
+		ensureError(t, err)
+		if _, offset := actual.Zone(); offset != 9*3600 {
+			t.Errorf("Actual: %d; Expected: %d", offset, 9*3600)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		actual, err := p.Parse("bogus")
+		if err == nil {
+			t.Fatalf("GOT: %s; WANT: error", actual)
+		}
+		if !actual.IsZero() {
+			t.Errorf("Actual: %s; Expected: zero time", actual)
+		}
+	})
+}
+
+func TestParserCalendarAnchors(t *testing.T) {
+	// Wednesday
+	base := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+	sunday := time.Sunday
+
+	cases := []struct {
+		name      string
+		weekStart *time.Weekday
+		value     string
+		expected  time.Time
+	}{
+		{"default", nil, "bow", time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)},
+		{"default", nil, "eow", time.Date(2020, time.November, 22, 23, 59, 59, 999999999, time.UTC)},
+		{"sunday", &sunday, "bow", time.Date(2020, time.November, 15, 0, 0, 0, 0, time.UTC)},
+		{"sunday", &sunday, "eow", time.Date(2020, time.November, 21, 23, 59, 59, 999999999, time.UTC)},
+		{"sunday", &sunday, "bow+9h", time.Date(2020, time.November, 15, 9, 0, 0, 0, time.UTC)},
+		{"sunday", &sunday, "bom", time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.name+" "+c.value, func(t *testing.T) {
+			p := NewParser("", nil)
+			p.Keywords["now"] = func() time.Time { return base }
+			p.WeekStart = c.weekStart
+			actual, err := p.Parse(c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("dict", func(t *testing.T) {
+		p := NewParser("", map[string]time.Time{"bow": base})
+		actual, err := p.Parse("bow+1h")
+		ensureError(t, err)
+		if expected := base.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("without now keyword", func(t *testing.T) {
+		p := Parser{WeekStart: &sunday}
+		_, err := p.Parse("bow")
+		if _, ok := err.(*time.ParseError); !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParserOnParse(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	var paths []string
+	var elapsed []time.Duration
+	p := NewParser(time.RFC3339, map[string]time.Time{"start": start})
+	p.Keywords["deploy"] = func() time.Time { return start }
+	p.OnParse = func(path string, d time.Duration) {
+		paths = append(paths, path)
+		elapsed = append(elapsed, d)
+	}
+
+	cases := []struct {
+		value, path string
+	}{
+		{"now-1h", "now"},
+		{"bow+1d", "now"},
+		{"deploy+1h", "keyword"},
+		{"start+1h", "dict"},
+		{"epoch+1d", "epoch"},
+		{"1609459200", "epoch"},
+		{"@1609459200", "epoch"},
+		{"2009-11-10T23:00:00Z", "layout"},
+		{"start+1x", "dict"},
+		{"bogus", "layout"},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			paths, elapsed = nil, nil
+			_, _ = p.Parse(c.value)
+			if len(paths) != 1 {
+				t.Fatalf("GOT: %d calls; WANT: 1", len(paths))
+			}
+			if paths[0] != c.path {
+				t.Errorf("GOT: %q; WANT: %q", paths[0], c.path)
+			}
+			if elapsed[0] < 0 {
+				t.Errorf("GOT: %s; WANT: non-negative", elapsed[0])
+			}
+		})
+	}
+
+	t.Run("extra", func(t *testing.T) {
+		paths = nil
+		_, err := p.ParseWithMap("end-1h", map[string]time.Time{"end": start})
+		ensureError(t, err)
+		if len(paths) != 1 || paths[0] != "dict" {
+			t.Errorf("GOT: %q; WANT: %q", paths, []string{"dict"})
+		}
+	})
+
+	t.Run("dict key named like a path", func(t *testing.T) {
+		p := Parser{Layout: time.RFC3339, Dict: map[string]time.Time{"epoch": start}, OnParse: p.OnParse}
+		paths = nil
+		_, err := p.Parse("epoch+1h")
+		ensureError(t, err)
+		if len(paths) != 1 || paths[0] != "dict" {
+			t.Errorf("GOT: %q; WANT: %q", paths, []string{"dict"})
+		}
+		paths = nil
+		_, err = p.Parse("1609459200")
+		ensureError(t, err)
+		if len(paths) != 1 || paths[0] != "epoch" {
+			t.Errorf("GOT: %q; WANT: %q", paths, []string{"epoch"})
+		}
+	})
+}
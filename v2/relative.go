@@ -0,0 +1,65 @@
+package tparse
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// RelativeTime holds an unresolved time expression relative to `now`, such as "now+1h", or a bare
+// duration such as "-30m", so it can be stored and resolved later against a base time that is not
+// yet known. It marshals to and from JSON as the original expression string.
+type RelativeTime struct {
+	expr string
+}
+
+// NewRelativeTime returns a RelativeTime for the expression, or an error when the expression cannot
+// be parsed.
+func NewRelativeTime(expr string) (RelativeTime, error) {
+	if _, err := decomposeRelative(expr); err != nil {
+		return RelativeTime{}, err
+	}
+	return RelativeTime{expr: expr}, nil
+}
+
+// decomposeRelative parses a duration expression, optionally prefixed by `now`.
+func decomposeRelative(expr string) (Offset, error) {
+	s := strings.TrimPrefix(expr, "now")
+	o, err := Decompose(s)
+	return o, relocate(err, expr, len(expr)-len(s))
+}
+
+// String returns the original expression.
+func (r RelativeTime) String() string { return r.expr }
+
+// Apply resolves the expression, substituting base for `now`.
+func (r RelativeTime) Apply(base time.Time) (time.Time, error) {
+	o, err := decomposeRelative(r.expr)
+	if err != nil {
+		return base, err
+	}
+	return o.Apply(base), nil
+}
+
+// MarshalJSON writes the original expression as a JSON string.
+func (r RelativeTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.expr)
+}
+
+// UnmarshalJSON reads an expression from a JSON string, returning an error when the expression
+// cannot be parsed.
+func (r *RelativeTime) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	var expr string
+	if err := json.Unmarshal(b, &expr); err != nil {
+		return err
+	}
+	rt, err := NewRelativeTime(expr)
+	if err != nil {
+		return err
+	}
+	*r = rt
+	return nil
+}
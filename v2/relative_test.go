@@ -0,0 +1,51 @@
+package tparse
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRelativeTimeUnmarshalJSON(t *testing.T) {
+	var config struct {
+		Since RelativeTime `json:"since"`
+	}
+
+	err := json.Unmarshal([]byte(`{"since":"now+1h"}`), &config)
+	ensureError(t, err)
+
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	actual, err := config.Since.Apply(base)
+	ensureError(t, err)
+	if expected := base.Add(time.Hour); actual != expected {
+		t.Errorf("Actual: %s; Expected: %s", actual, expected)
+	}
+}
+
+func TestRelativeTimeUnmarshalJSONError(t *testing.T) {
+	var rt RelativeTime
+
+	err := json.Unmarshal([]byte(`"now+1xq"`), &rt)
+	ensureError(t, err, `unknown unit "xq"`)
+
+	if e, ok := err.(*ParseError); !ok || e.Input != "now+1xq" || e.Offset != 5 {
+		t.Errorf("GOT: %#v; WANT: offset 5 of %q", err, "now+1xq")
+	}
+}
+
+func TestRelativeTimeMarshalJSON(t *testing.T) {
+	for _, expr := range []string{"now+1h", "now-2d3h", "-30m"} {
+		rt, err := NewRelativeTime(expr)
+		ensureError(t, err)
+
+		b, err := json.Marshal(rt)
+		ensureError(t, err)
+
+		var other RelativeTime
+		ensureError(t, json.Unmarshal(b, &other))
+
+		if got, want := other.String(), expr; got != want {
+			t.Errorf("GOT: %q; WANT: %q", got, want)
+		}
+	}
+}
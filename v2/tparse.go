@@ -1,9 +1,11 @@
 package tparse
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,31 +15,160 @@ func fractionToNanos(fraction float64) int64 {
 	return int64(fraction * float64(time.Second/time.Nanosecond))
 }
 
+// whitespace is the set of bytes that may separate the terms of a duration.
+const whitespace = " \t\n\v\f\r"
+
+// errDurationOverflow is returned when units that are summed as a time.Duration exceed its range.
+var errDurationOverflow = errors.New("duration overflow: exceeds roughly 292 years; use month or year units")
+
 var unitMap = map[string]float64{
-	"ns":      float64(time.Nanosecond),
-	"us":      float64(time.Microsecond),
-	"µs":      float64(time.Microsecond), // U+00B5 = micro symbol
-	"μs":      float64(time.Microsecond), // U+03BC = Greek letter mu
-	"ms":      float64(time.Millisecond),
-	"s":       float64(time.Second),
-	"sec":     float64(time.Second),
-	"second":  float64(time.Second),
-	"seconds": float64(time.Second),
-	"m":       float64(time.Minute),
-	"min":     float64(time.Minute),
-	"minute":  float64(time.Minute),
-	"minutes": float64(time.Minute),
-	"h":       float64(time.Hour),
-	"hr":      float64(time.Hour),
-	"hour":    float64(time.Hour),
-	"hours":   float64(time.Hour),
-	"d":       float64(time.Hour * 24),
-	"day":     float64(time.Hour * 24),
-	"days":    float64(time.Hour * 24),
-	"w":       float64(time.Hour * 24 * 7),
-	"week":    float64(time.Hour * 24 * 7),
-	"weeks":   float64(time.Hour * 24 * 7),
-	"wk":      float64(time.Hour * 24 * 7),
+	"ns":           float64(time.Nanosecond),
+	"us":           float64(time.Microsecond),
+	"µs":           float64(time.Microsecond), // U+00B5 = micro symbol
+	"μs":           float64(time.Microsecond), // U+03BC = Greek letter mu
+	"ms":           float64(time.Millisecond),
+	"cs":           float64(10 * time.Millisecond),
+	"centisecond":  float64(10 * time.Millisecond),
+	"centiseconds": float64(10 * time.Millisecond),
+	"ds":           float64(100 * time.Millisecond),
+	"decisecond":   float64(100 * time.Millisecond),
+	"deciseconds":  float64(100 * time.Millisecond),
+	"s":            float64(time.Second),
+	"sec":          float64(time.Second),
+	"second":       float64(time.Second),
+	"seconds":      float64(time.Second),
+	"m":            float64(time.Minute),
+	"min":          float64(time.Minute),
+	"minute":       float64(time.Minute),
+	"minutes":      float64(time.Minute),
+	"h":            float64(time.Hour),
+	"hr":           float64(time.Hour),
+	"hour":         float64(time.Hour),
+	"hours":        float64(time.Hour),
+	"d":            float64(time.Hour * 24),
+	"day":          float64(time.Hour * 24),
+	"days":         float64(time.Hour * 24),
+	"w":            float64(time.Hour * 24 * 7),
+	"week":         float64(time.Hour * 24 * 7),
+	"weeks":        float64(time.Hour * 24 * 7),
+	"wk":           float64(time.Hour * 24 * 7),
+}
+
+// calendarUnit identifies units whose length depends on the calendar.
+type calendarUnit int
+
+const (
+	calendarMonth calendarUnit = iota
+	calendarYear
+)
+
+var calendarUnitMap = map[string]calendarUnit{
+	"mo":     calendarMonth,
+	"mon":    calendarMonth,
+	"month":  calendarMonth,
+	"months": calendarMonth,
+	"y":      calendarYear,
+	"yr":     calendarYear,
+	"year":   calendarYear,
+	"years":  calendarYear,
+}
+
+// unknownUnitError returns an error for an unrecognized unit, suggesting the closest recognized
+// unit when one is similar enough to have plausibly been intended.
+func unknownUnitError(unit string) error {
+	if suggestion := suggestUnit(unit); suggestion != "" {
+		return fmt.Errorf("unknown unit %q; did you mean %q?", unit, suggestion)
+	}
+	return fmt.Errorf("unknown unit %q", unit)
+}
+
+// suggestUnit returns the recognized unit with the smallest edit distance from unit, or the empty
+// string when no unit is within an edit distance of two. Ties prefer the unit whose length is
+// closest to that of unit, then lexical order.
+func suggestUnit(unit string) string {
+	const maxDistance = 2
+	var best string
+	bestDistance, bestLength := maxDistance+1, 0
+	for _, candidate := range Units() {
+		distance := editDistance(unit, candidate)
+		length := len(candidate) - len(unit)
+		if length < 0 {
+			length = -length
+		}
+		if distance < bestDistance || (distance == bestDistance && length < bestLength) {
+			best, bestDistance, bestLength = candidate, distance, length
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// canonicalUnit returns a value identifying the quantity measured by a recognized unit, so that
+// different spellings of the same unit, such as "h" and "hour", compare equal.
+func (p *Parser) canonicalUnit(unit string) interface{} {
+	if months, ok := p.MonthUnits[unit]; ok {
+		return monthCount(months)
+	}
+	if duration, ok := p.DurationUnits[unit]; ok {
+		return float64(duration)
+	}
+	if duration, ok := unitMap[unit]; ok {
+		return duration
+	}
+	if c, ok := calendarUnitMap[unit]; ok {
+		if c == calendarYear {
+			return monthCount(12)
+		}
+		return monthCount(1)
+	}
+	return unit // resolved by UnknownUnit
+}
+
+// monthCount is the canonical form of a calendar unit, as a number of months.
+type monthCount int
+
+// Units returns every unit token recognized in a duration string, sorted in lexical order.
+func Units() []string {
+	units := make([]string, 0, len(unitMap)+len(calendarUnitMap))
+	for unit := range unitMap {
+		units = append(units, unit)
+	}
+	for unit := range calendarUnitMap {
+		units = append(units, unit)
+	}
+	sort.Strings(units)
+	return units
 }
 
 // AbsoluteDuration returns the time.Duration between the base time and the
@@ -55,16 +186,43 @@ func AbsoluteDuration(base time.Time, duration string) (time.Duration, error) {
 }
 
 // AddDuration parses the duration string, and adds the calculated duration value to the provided
-// base time. On error, it returns the base time and the error.
+// base time. On error, it returns the base time and a *ParseError describing where in the
+// duration string the problem was found.
 //
 // Like `time.ParseDuration`, this accepts multiple fractional scalars, so "now+1.5days-3.21hours"
-// is evaluated properly.
+// is evaluated properly. Digits may be omitted on either side of the decimal point, but not both, so
+// ".5h" is half an hour, "5.h" is five hours, and ".h" is an error. Every number requires a unit,
+// except for a zero term, so that "now+0" is valid, but "now+5" is not.
+//
+// Each term may be preceded by its own sign, which applies to that term alone. A term without a sign
+// is added, regardless of the sign of the term before it, so "-1h2m" is one hour earlier and two
+// minutes later, or fifty eight minutes earlier. To subtract several terms, sign each of them, as
+// in "-1h-2m", or use the "ago" suffix described below.
+//
+// Whitespace may separate terms, and may appear between a number and its unit, so "3 days 4 hours"
+// is equivalent to "3days4hours". A standalone "and" between terms is ignored, so "1 day and 2
+// hours" is equivalent to "1day2hours". The words "plus" and "minus", followed by whitespace, may
+// be used in place of the signs, so "minus 30 minutes" is equivalent to "-30minutes". A trailing
+// "ago" token, separated from the rest of the duration by whitespace, negates the entire span after
+// its terms have been summed. Because it is applied last, "ago" flips any explicit sign, so "-1h
+// ago" adds one hour.
+//
+// Units up to and including weeks are summed as a time.Duration, which cannot exceed roughly 292
+// years, so a duration whose such units sum beyond that range, as in "1000000000h", is an error
+// rather than silently wrapping around. Months and years are added using time.Time.AddDate, and are
+// not subject to this limit.
+//
+// Full width forms of digits, signs, and letters, such as "１ｈ", are treated as their ASCII
+// equivalents. When such a duration string cannot be parsed, the Input of the returned *ParseError
+// is the string after that replacement.
 //
 // The following tokens may be used to specify the respective unit of time:
 //
 // * Nanosecond: ns
 // * Microsecond: us, µs (U+00B5 = micro symbol), μs (U+03BC = Greek letter mu)
 // * Millisecond: ms
+// * Centisecond: cs, centisecond, centiseconds
+// * Decisecond: ds, decisecond, deciseconds
 // * Second: s, sec, second, seconds
 // * Minute: m, min, minute, minutes
 // * Hour: h, hr, hour, hours
@@ -94,112 +252,504 @@ func AbsoluteDuration(base time.Time, duration string) (time.Duration, error) {
 //		fmt.Printf("time is: %s\n", another)
 //	}
 func AddDuration(base time.Time, s string) (time.Time, error) {
-	if len(s) == 0 {
-		return base, nil
+	o, err := Decompose(s)
+	if err != nil {
+		return base, err
 	}
-	var isNegative bool
-	var exp, whole, fraction int64
-	var number, totalYears, totalMonths, totalDays, totalDuration float64
+	return o.Apply(base), nil
+}
 
-	for s != "" {
-		// consume possible sign
-		if s[0] == '+' {
-			if len(s) == 1 {
-				return base, fmt.Errorf("cannot parse sign without digits: '+'")
-			}
-			isNegative = false
-			s = s[1:]
-		} else if s[0] == '-' {
-			if len(s) == 1 {
-				return base, fmt.Errorf("cannot parse sign without digits: '-'")
+// AddDurations adds each of the duration strings to the same base time, and returns the results and
+// errors in slices parallel to exprs. Where a duration string cannot be parsed, the result is the
+// base time and the error is a *ParseError; elsewhere the error is nil. Using one base for every
+// expression avoids the drift of calling time.Now for each one.
+func AddDurations(base time.Time, exprs []string) ([]time.Time, []error) {
+	times := make([]time.Time, len(exprs))
+	errs := make([]error, len(exprs))
+	for i, s := range exprs {
+		times[i], errs[i] = AddDuration(base, s)
+	}
+	return times, errs
+}
+
+// ApplyUnits adds the specified number of each unit to base, summing and collapsing them exactly as
+// AddDuration does, so that callers assembling an offset programmatically need not format it as a
+// duration string. The keys of units are the unit tokens recognized by AddDuration, as in
+// map[string]float64{"h": 1.5, "mo": 2}. On error, it returns the base time and the error.
+func ApplyUnits(base time.Time, units map[string]float64) (time.Time, error) {
+	keys := make([]string, 0, len(units))
+	for unit := range units {
+		keys = append(keys, unit)
+	}
+	sort.Strings(keys) // report the same unknown unit every time
+
+	var totals accumulator
+	for _, unit := range keys {
+		if unit == "" {
+			return base, errors.New("duration missing units")
+		}
+		if !defaultParser.add(&totals, units[unit], unit) {
+			return base, unknownUnitError(unit)
+		}
+	}
+	if o := totals.offset(); !defaultParser.overflows(o) {
+		return o.Apply(base), nil
+	}
+	return base, errDurationOverflow
+}
+
+// OffsetSeconds returns the number of seconds the duration string spans when added to base. Calendar
+// units are resolved against base, so "1mo" from the first of January is thirty one days. This
+// suits consumers that need a single number of seconds, such as range selectors.
+func OffsetSeconds(base time.Time, s string) (float64, error) {
+	t, err := AddDuration(base, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.Sub(base).Seconds(), nil
+}
+
+// ParseDuration parses the duration string using the same grammar as AddDuration, and returns the
+// time.Duration it describes. Because the length of a month or year depends on the time it is
+// added to, a duration string that uses month or year units returns an error. An empty duration
+// string returns zero.
+func ParseDuration(s string) (time.Duration, error) {
+	return fixedDuration(s, "cannot parse duration %q: months and years have no fixed length")
+}
+
+// fixedDuration parses the duration string like ParseDuration does, collapsing days into
+// twenty-four hours each, and returns an error formatted from calendarFormat and s when the duration
+// string uses month or year units.
+func fixedDuration(s, calendarFormat string) (time.Duration, error) {
+	o, err := Decompose(s)
+	if err != nil {
+		return 0, err
+	}
+	if o.Years != 0 || o.Months != 0 {
+		return 0, fmt.Errorf(calendarFormat, s)
+	}
+	return o.Duration + time.Duration(o.Days*float64(24*time.Hour)), nil
+}
+
+// Timeout parses the duration string like ParseDuration, for use as a timeout, for instance with
+// context.WithTimeout. Units from nanoseconds through weeks are accepted, with days and weeks
+// being fixed spans of twenty-four hours per day. Months and years are rejected, because their
+// length depends on when the timeout starts.
+func Timeout(s string) (time.Duration, error) {
+	return fixedDuration(s, "cannot use %q as a timeout: months and years vary in length depending on when the timeout starts; use days or weeks")
+}
+
+// ParseDurationStrict is like ParseDuration, but returns an error when the duration string is empty
+// or contains only whitespace, for callers where an empty value is a mistake rather than zero.
+func ParseDurationStrict(s string) (time.Duration, error) {
+	if strings.TrimLeft(s, whitespace) == "" {
+		return 0, errors.New("empty duration")
+	}
+	return ParseDuration(s)
+}
+
+// Decompose parses the duration string using the same grammar as AddDuration, and returns the
+// Offset it describes without applying it to any base time. On error, it returns a *ParseError
+// describing where in the duration string the problem was found.
+func Decompose(s string) (Offset, error) {
+	return defaultParser.Decompose(s)
+}
+
+// Decompose is like the Decompose function, but parses using the options of the Parser.
+func (p *Parser) Decompose(s string) (Offset, error) {
+	var isAgo bool
+	var seen map[interface{}]struct{} // canonical units seen when strict
+	var totals accumulator
+	s = normalizeWidth(s)
+	input := s
+
+	// trailing "ago" token negates the entire span
+	if t := strings.TrimRight(s, whitespace); strings.HasSuffix(t, "ago") {
+		if t = t[:len(t)-3]; t == "" || strings.IndexByte(whitespace, t[len(t)-1]) >= 0 {
+			if strings.TrimLeft(t, whitespace) == "" {
+				return Offset{}, newParseError(input, strings.TrimLeft(s, whitespace), errors.New(`missing duration before "ago"`))
 			}
-			isNegative = true
-			s = s[1:]
+			isAgo = true
+			s = t
 		}
-		// consume digits
-		var done bool
-		for !done && len(s) > 0 {
-			c := s[0]
-			switch {
-			case c >= '0' && c <= '9':
-				d := int64(c - '0')
-				if exp > 0 {
-					exp++
-					fraction = 10*fraction + d
-				} else {
-					whole = 10*whole + d
-				}
-				s = s[1:]
-			case c == '.':
-				if exp > 0 {
-					return base, fmt.Errorf("invalid floating point number format: two decimal points found")
-				}
-				exp = 1
-				fraction = 0
-				s = s[1:]
-			default:
-				done = true
+	}
+
+	for {
+		s = strings.TrimLeft(s, whitespace)
+		if hasWord(s, "and") {
+			// connector between terms: no-op
+			s = strings.TrimLeft(s[3:], whitespace)
+		}
+		if s == "" {
+			break
+		}
+		term := s
+		// consume possible sign, which applies to this term only
+		var sign string
+		var isNegative bool
+		switch {
+		case s[0] == '+':
+			sign = "+"
+		case s[0] == '-':
+			sign, isNegative = "-", true
+		case hasWord(s, "plus"):
+			sign = "plus"
+		case hasWord(s, "minus"):
+			sign, isNegative = "minus", true
+		}
+		if sign != "" {
+			rest := strings.TrimLeft(s[len(sign):], whitespace)
+			if rest == "" {
+				return Offset{}, newParseError(input, s, fmt.Errorf("cannot parse sign without digits: '%s'", sign))
 			}
+			s = rest
 		}
-		// adjust number
-		number = float64(whole)
-		if exp > 0 {
-			number += float64(fraction) * math.Pow(10, float64(1-exp))
+		number, rest, ok := p.spelledFraction(s)
+		if !ok {
+			var err error
+			if number, rest, err = parseNumber(input, s); err != nil {
+				return Offset{}, err
+			}
 		}
 		if isNegative {
 			number *= -1
 		}
-		// find end of unit
-		var i int
-		for ; i < len(s) && s[i] != '+' && s[i] != '-' && (s[i] < '0' || s[i] > '9'); i++ {
-			// identifier bytes: no-op
+		digits := strings.TrimRight(s[:len(s)-len(rest)], whitespace)
+		if p.Natural && len(rest) > 1 && rest[0] == '-' && isLetter(rest[1]) {
+			// hyphen connecting a number to its unit word, as in "30-minutes": no-op
+			rest = rest[1:]
 		}
-		unit := s[:i]
+		s = strings.TrimLeft(rest, whitespace)
+		unit := s[:unitLength(s)]
 		// fmt.Printf("number: %f; unit: %q\n", number, unit)
-		if duration, ok := unitMap[unit]; ok {
-			totalDuration += number * duration
-		} else {
-			switch unit {
-			case "mo", "mon", "month", "months":
-				totalMonths += number
-			case "y", "yr", "year", "years":
-				totalYears += number
-			default:
-				if unit == "" {
-					return base, errors.New("duration missing units")
+		if unit == "" && number == 0 && digits != "" && (s == "" || s[0] == '+' || s[0] == '-') {
+			// zero is unambiguous without a unit, as in "now+0"
+			continue
+		}
+		if !p.add(&totals, number, unit) {
+			if unit == "" {
+				return Offset{}, newParseError(input, s, fmt.Errorf("missing unit after number %q", digits))
+			}
+			return Offset{}, newParseError(input, s, unknownUnitError(unit))
+		}
+		if math.Abs(totals.duration) >= math.MaxInt64 {
+			return Offset{}, newParseError(input, term, errDurationOverflow)
+		}
+		if p.Strict {
+			key := p.canonicalUnit(unit)
+			if _, ok := seen[key]; ok {
+				return Offset{}, newParseError(input, s, fmt.Errorf("duplicate unit %q", unit))
+			}
+			if seen == nil {
+				seen = make(map[interface{}]struct{})
+			}
+			seen[key] = struct{}{}
+		}
+		s = s[len(unit):]
+	}
+	if isAgo {
+		totals.negate()
+	}
+	o := totals.offset()
+	if p.overflows(o) {
+		// Fractional years, months, and days pushed the duration out of range.
+		return Offset{}, newParseError(input, input, errDurationOverflow)
+	}
+	return o, nil
+}
+
+// AddDurationStopwatch is like AddDuration, but accepts durations written the way a stopwatch
+// reads, in which a final number without a unit takes the next smaller unit than the term before
+// it: minutes after an hour unit, as in "1h30" for one hour and thirty minutes, and seconds after a
+// minute unit, as in "1m30" for one minute and thirty seconds. Only the final number inherits a
+// unit, it must end the duration string, and it takes the sign before it, if any, as in "1h-15"
+// for forty five minutes. Every other number requires a unit, as it does for AddDuration, which
+// rejects "1h30".
+func AddDurationStopwatch(base time.Time, s string) (time.Time, error) {
+	t := strings.TrimRight(s, whitespace)
+	i := len(t)
+	for i > 0 && (t[i-1] >= '0' && t[i-1] <= '9' || t[i-1] == '.') {
+		i--
+	}
+	if i == len(t) {
+		return AddDuration(base, s)
+	}
+	j := i
+	if j > 0 && (t[j-1] == '+' || t[j-1] == '-') {
+		j--
+	}
+	prefix := strings.TrimRight(t[:j], whitespace)
+	k := len(prefix)
+	for k > 0 && unitLength(prefix[k-1:k]) == 1 {
+		k--
+	}
+	var unit string
+	switch unitMap[prefix[k:]] {
+	case float64(time.Hour):
+		unit = "m"
+	case float64(time.Minute):
+		unit = "s"
+	default:
+		return AddDuration(base, s)
+	}
+	result, err := AddDuration(base, t+unit)
+	if e, ok := err.(*ParseError); ok {
+		// Report the problem in the input as written, without the inherited unit.
+		return result, &ParseError{Input: s, Offset: e.Offset, Err: e.Err}
+	}
+	return result, err
+}
+
+// AddDurationUnitFirst is like AddDuration, but parses durations in which each unit precedes its
+// number, such as "h2m30" for two hours and thirty minutes. An optional sign may precede each unit,
+// as in "h2-m30", and applies to that term alone. This is an alternate grammar for legacy inputs,
+// and it does not support the "ago" suffix.
+func AddDurationUnitFirst(base time.Time, s string) (time.Time, error) {
+	var totals accumulator
+	input := s
+
+	for {
+		s = strings.TrimLeft(s, whitespace)
+		if s == "" {
+			break
+		}
+		term := s
+		// consume possible sign, which applies to this term only
+		var isNegative bool
+		if s[0] == '+' {
+			s = strings.TrimLeft(s[1:], whitespace)
+		} else if s[0] == '-' {
+			isNegative = true
+			s = strings.TrimLeft(s[1:], whitespace)
+		}
+		unit := s[:unitLength(s)]
+		if unit == "" {
+			return base, newParseError(input, s, errors.New("duration missing units"))
+		}
+		s = strings.TrimLeft(s[len(unit):], whitespace)
+		number, rest, err := parseNumber(input, s)
+		if err != nil {
+			return base, err
+		}
+		if rest == s {
+			return base, newParseError(input, s, fmt.Errorf("missing number after unit: %q", unit))
+		}
+		if isNegative {
+			number *= -1
+		}
+		if !totals.add(number, unit) {
+			return base, newParseError(input, s, unknownUnitError(unit))
+		}
+		if math.Abs(totals.duration) >= math.MaxInt64 {
+			return base, newParseError(input, term, errDurationOverflow)
+		}
+		s = rest
+	}
+	o := totals.offset()
+	if defaultParser.overflows(o) {
+		return base, newParseError(input, input, errDurationOverflow)
+	}
+	return o.Apply(base), nil
+}
+
+// normalizeWidth replaces full width forms of ASCII characters, such as the digits U+FF10 through
+// U+FF19, with their ASCII equivalents, and the ideographic space with a space, so that durations
+// pasted from text written with an East Asian input method parse as expected. It returns s when
+// there is nothing to replace.
+func normalizeWidth(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '\uFF01' && r <= '\uFF5E':
+			return r - '\uFF01' + '!'
+		case r == '\u3000':
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// spelledFractions are the words recognized in place of a number in Natural mode.
+var spelledFractions = map[string]float64{
+	"half":    0.5,
+	"quarter": 0.25,
+}
+
+// spelledFraction returns the fraction named by the word at the start of s, and the remainder of s
+// after that word and any following "a" or "an", as in "half an hour", when the Parser is in Natural
+// mode. It returns false when not in Natural mode, or when s does not start with one of the words
+// followed by whitespace.
+func (p *Parser) spelledFraction(s string) (float64, string, bool) {
+	if !p.Natural {
+		return 0, s, false
+	}
+	for word, fraction := range spelledFractions {
+		if hasWord(s, word) {
+			rest := strings.TrimLeft(s[len(word):], whitespace)
+			for _, article := range []string{"an", "a"} {
+				if hasWord(rest, article) {
+					rest = rest[len(article):]
+					break
+				}
+			}
+			return fraction, rest, true
+		}
+	}
+	return 0, s, false
+}
+
+// isLetter returns true when c is an ASCII letter.
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// hasWord returns true when s starts with word followed by whitespace.
+func hasWord(s, word string) bool {
+	return len(s) > len(word) && strings.HasPrefix(s, word) && strings.IndexByte(whitespace, s[len(word)]) >= 0
+}
+
+// parseNumber consumes the decimal number at the start of s, which must be a suffix of input,
+// returning its value and the remainder of s. When s does not start with a number, it returns zero
+// and s unchanged. A decimal point without any digits is not a number.
+func parseNumber(input, s string) (float64, string, error) {
+	var exp, whole, fraction int64
+	var sawDigit bool
+	digits := s
+
+	for len(s) > 0 {
+		c := s[0]
+		if c != '.' && (c < '0' || c > '9') {
+			break
+		}
+		switch {
+		case c >= '0' && c <= '9':
+			sawDigit = true
+			d := int64(c - '0')
+			if exp > 0 {
+				// Fractional digits beyond the precision of int64 cannot change the float64
+				// result, so they are consumed but ignored.
+				if fraction <= (math.MaxInt64-d)/10 {
+					exp++
+					fraction = 10*fraction + d
 				}
-				return base, fmt.Errorf("unknown unit in duration: %q", unit)
+			} else {
+				if whole > (math.MaxInt64-d)/10 {
+					return 0, s, newParseError(input, digits, errors.New("numeric overflow in duration"))
+				}
+				whole = 10*whole + d
+			}
+			s = s[1:]
+		case c == '.':
+			if exp > 0 {
+				return 0, s, newParseError(input, s, fmt.Errorf("%w: two decimal points found", ErrMalformedNumber))
 			}
+			exp = 1
+			s = s[1:]
 		}
+	}
+	if exp > 0 && !sawDigit {
+		return 0, s, newParseError(input, digits, fmt.Errorf("%w: no digits found", ErrMalformedNumber))
+	}
+	return adjustNumber(whole, fraction, exp), s, nil
+}
 
-		s = s[i:]
-		whole = 0
+// adjustNumber returns the value of the whole and fractional parts of a number, where exp is one
+// more than the number of fractional digits, or zero when the number has no decimal point. A
+// fractional part too small to represent, such as that of "0.0000000000000000000000000001",
+// underflows to zero, and one that is not finite is treated as zero.
+func adjustNumber(whole, fraction, exp int64) float64 {
+	number := float64(whole)
+	if exp > 0 {
+		if f := float64(fraction) * math.Pow(10, float64(1-exp)); !math.IsNaN(f) && !math.IsInf(f, 0) {
+			number += f
+		}
 	}
-	if totalYears != 0 {
-		whole := math.Trunc(totalYears)
-		fraction := totalYears - whole
-		totalYears = whole
-		totalMonths += 12 * fraction
+	return number
+}
+
+// unitLength returns the length of the unit at the start of s, which ends at a sign, a digit,
+// whitespace, or the end of s.
+func unitLength(s string) int {
+	var i int
+	for ; i < len(s) && s[i] != '+' && s[i] != '-' && (s[i] < '0' || s[i] > '9') && strings.IndexByte(whitespace, s[i]) < 0; i++ {
+		// identifier bytes: no-op
 	}
-	if totalMonths != 0 {
-		whole := math.Trunc(totalMonths)
-		fraction := totalMonths - whole
-		totalMonths = whole
-		totalDays += 30 * fraction
+	return i
+}
+
+// AddSeconds parses a plain decimal number of seconds, with an optional sign and fractional part,
+// and adds that many seconds to the provided base time. Unlike AddDuration it does not accept
+// units, exponents, or whitespace, which allows it to skip unit parsing. On error, it returns the
+// base time and the error.
+func AddSeconds(base time.Time, seconds string) (time.Time, error) {
+	var digits, points int
+	for i := 0; i < len(seconds); i++ {
+		switch c := seconds[i]; {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.':
+			points++
+		case (c == '+' || c == '-') && i == 0:
+			// leading sign: no-op
+		default:
+			return base, fmt.Errorf("cannot parse seconds: %q", seconds)
+		}
 	}
-	if totalDays != 0 {
-		whole := math.Trunc(totalDays)
-		fraction := totalDays - whole
-		totalDays = whole
-		totalDuration += (fraction * 24.0 * float64(time.Hour))
+	if digits == 0 || points > 1 {
+		return base, fmt.Errorf("cannot parse seconds: %q", seconds)
 	}
-	if totalYears != 0 || totalMonths != 0 || totalDays != 0 {
-		base = base.AddDate(int(totalYears), int(totalMonths), int(totalDays))
+	f, err := strconv.ParseFloat(seconds, 64)
+	if err != nil {
+		return base, err
 	}
-	if totalDuration != 0 {
-		base = base.Add(time.Duration(totalDuration))
+	nanos := f * float64(time.Second)
+	if nanos >= math.MaxInt64 || nanos <= math.MinInt64 {
+		return base, fmt.Errorf("cannot parse seconds: %q: numeric overflow", seconds)
 	}
-	return base, nil
+	return base.Add(time.Duration(nanos)), nil
+}
+
+// ParseRelativeSeconds interprets value as a number of seconds relative to the current time, so
+// "300" is five minutes from now and "-300" is five minutes ago. A number without a sign is in the
+// future. Unlike Parse, a bare number is never treated as an epoch value. The value is parsed like
+// AddSeconds.
+func ParseRelativeSeconds(value string) (time.Time, error) {
+	return AddSeconds(time.Now(), value)
+}
+
+// ParseClockDuration parses a duration written as colon separated clock fields. Three fields are
+// hours, minutes, and seconds, as in "01:30:00", and two fields are minutes and seconds, so "90:00"
+// is ninety minutes. Only the seconds field may have a fractional part, as in "1:2:3.5". Fields are
+// not limited to the range of a clock face, and signs are not accepted.
+func ParseClockDuration(s string) (time.Duration, error) {
+	fields := strings.Split(s, ":")
+	if len(fields) < 2 || len(fields) > 3 {
+		return 0, fmt.Errorf("cannot parse clock duration: expected two or three fields: %q", s)
+	}
+	var total float64
+	last := len(fields) - 1
+	for i, field := range fields {
+		var points int
+		for j := 0; j < len(field); j++ {
+			if c := field[j]; c == '.' && i == last {
+				points++
+			} else if c < '0' || c > '9' {
+				return 0, fmt.Errorf("cannot parse clock duration: invalid field %q: %q", field, s)
+			}
+		}
+		if field == "" || field == "." || points > 1 {
+			return 0, fmt.Errorf("cannot parse clock duration: invalid field %q: %q", field, s)
+		}
+		f, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot parse clock duration: %s", err)
+		}
+		total = 60*total + f
+	}
+	nanos := total * float64(time.Second)
+	if nanos >= math.MaxInt64 {
+		return 0, fmt.Errorf("cannot parse clock duration: numeric overflow: %q", s)
+	}
+	return time.Duration(nanos), nil
 }
 
 // Parse will return the time value corresponding to the specified layout and value.  It also parses
@@ -212,11 +762,32 @@ func Parse(layout, value string) (time.Time, error) {
 // parses floating point and integer epoch values.  It recognizes the special string `now` and
 // replaces that with the time ParseNow is called.  This allows a suffix adding or subtracting
 // various values from the base time.  For instance, ParseNow(time.ANSIC, "now+1d") will return a
-// time corresponding to 24 hours from the moment the function is invoked.
+// time corresponding to 24 hours from the moment the function is invoked. Like the result of
+// time.Now, a time relative to `now` carries a monotonic clock reading; use time.Time.Round(0) to
+// strip it before comparing the time with one that has been serialized.
 //
 // In addition to the duration abbreviations recognized by time.ParseDuration, it recognizes various
 // tokens for days, weeks, months, and years.
 //
+// It also recognizes "next <weekday>" and "last <weekday>", which resolve to midnight of the nearest
+// following or preceding occurrence of that weekday, and may likewise be followed by a duration, as
+// in "next monday+9h". When today is that weekday, "next" is seven days ahead and "last" is seven
+// days back.
+//
+// An ordinal day of the current, following, or preceding month, as in "1st of next month", "the
+// 15th of this month", or "31st of last month", resolves to midnight of that day. Such a day that
+// does not exist in its month, such as the 31st of a month with thirty days, is an error rather than
+// being clamped to the last day of the month.
+//
+// The words "today", "tomorrow", and "yesterday" resolve to midnight of those days. Following any
+// of these words, a weekday expression, or an ordinal day expression, a clock time such as
+// "T15:30", "T09:00:00", or "3:30pm" sets the wall clock time of that day, so "tomorrow T09:00" is
+// nine in the morning tomorrow, and "today 12:00am" is midnight.
+//
+// Likewise, "bod", "bow", "bom", and "boy" resolve to midnight at the beginning of the current day,
+// week, month, and year, and "eod", "eow", "eom", and "eoy" to the last nanosecond of them, so
+// "bom-1mo" is the beginning of last month. Weeks begin on Monday.
+//
 //	package main
 //
 //	import (
@@ -237,17 +808,365 @@ func Parse(layout, value string) (time.Time, error) {
 //		fmt.Printf("time is: %s\n", actual)
 //	}
 func ParseNow(layout, value string) (time.Time, error) {
+	return parseNow(time.Now(), layout, value, nil, nil, nil)
+}
+
+// ParseRelativeTo parses value like ParseNow does, but resolves `now`, and the other values relative
+// to the current time, such as "today" and "bom", against the reference time in refValue instead of
+// the clock, which makes the result deterministic. The reference time is parsed like ParseWithMap
+// using refLayout, without a dict, so it may also be an epoch value. Like ParseWithMap, value may
+// also be relative to a key in dict.
+//
+//	t, err := tparse.ParseRelativeTo(time.RFC3339, "2009-11-10T23:00:00Z", "", "now+1h", nil)
+func ParseRelativeTo(refLayout, refValue, layout, value string, dict map[string]time.Time) (time.Time, error) {
+	ref, err := defaultParser.parseWithMap(refLayout, refValue, nil, nil, true, nil)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("cannot parse reference time: %w", err)
+	}
+	return parseNow(ref, layout, value, dict, nil, nil)
+}
+
+// ParseNowDetailed is like ParseNow, but also returns the current time it resolved `now` to, so
+// that for a value such as "now-90m", result.Sub(base) is the offset that was applied. Because
+// calendar units depend on the base time, that offset may differ for another base. The base is
+// returned even when value is not relative to `now`.
+func ParseNowDetailed(layout, value string) (base, result time.Time, err error) {
+	base = time.Now()
+	result, err = parseNow(base, layout, value, nil, nil, nil)
+	return base, result, err
+}
+
+// ParseNowFormat parses value like ParseNow using inLayout, and returns the result formatted using
+// outLayout. When outLayout is empty, the result is formatted using time.RFC3339.
+func ParseNowFormat(inLayout, value, outLayout string) (string, error) {
+	t, err := ParseNow(inLayout, value)
+	if err != nil {
+		return "", err
+	}
+	if outLayout == "" {
+		outLayout = time.RFC3339
+	}
+	return t.Format(outLayout), nil
+}
+
+// ParseNowUTC is like ParseNow, but anchors the special string `now` to the current time in UTC,
+// so the returned time is in UTC and calendar arithmetic for days, months, and years is not subject
+// to the daylight saving time transitions of the local time zone.
+func ParseNowUTC(layout, value string) (time.Time, error) {
+	return parseNow(time.Now().UTC(), layout, value, nil, nil, nil)
+}
+
+// ParseNowContext is like ParseNow, but anchors the special string `now` to the current time in the
+// provided location, and parses absolute values using layout in that location, as
+// ParseWithMapInLocation does. Because the location is loaded by the caller, parsing performs no
+// blocking I/O. The context is accepted so that a deadline can be threaded through to any slower
+// path added in the future; no current path consults it, so parsing succeeds even when the context
+// is already cancelled.
+func ParseNowContext(ctx context.Context, layout, value string, loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		return parseNow(time.Now(), layout, value, nil, nil, nil)
+	}
+	return parseNow(time.Now().In(loc), layout, value, nil, loc, nil)
+}
+
+// parseNow resolves the special string `now` to the provided time, "today", "tomorrow",
+// "yesterday", "next <weekday>", "last <weekday>", and ordinal days such as "1st of next month" to
+// midnight of that day relative to it, optionally followed by a clock time, and the beginning and
+// end of calendar units, such as "bom", relative to it, and otherwise parses value like
+// ParseWithMapInLocation. Like ParseWithMap, a value starting with a key in dict is always relative
+// to that key, so a key such as "now_deploy" or "today" takes precedence over these words. When x
+// is not nil, it records how value was resolved.
+func parseNow(now time.Time, layout, value string, dict map[string]time.Time, loc *time.Location, x *Explanation) (time.Time, error) {
+	if longestKey(value, dict) != "" {
+		return defaultParser.parseWithMap(layout, value, dict, loc, true, x)
+	}
 	if strings.HasPrefix(value, "now") {
-		return AddDuration(time.Now(), value[3:])
+		x.set("now", value[3:])
+		t, err := AddDuration(now, value[3:])
+		return t, relocate(err, value, 3)
 	}
-	return ParseWithMap(layout, value, nil)
+	if anchor, rest, ok := relativeDay(now, value); ok {
+		return addAfterClock(value, anchor, rest, x)
+	}
+	if anchor, rest, ok := relativeWeekday(now, value); ok {
+		return addAfterClock(value, anchor, rest, x)
+	}
+	if anchor, rest, ok, err := ordinalOfMonth(now, value); ok {
+		if err != nil {
+			return anchor, err
+		}
+		return addAfterClock(value, anchor, rest, x)
+	}
+	if anchor, rest, ok := calendarAnchor(now, value, time.Monday); ok {
+		x.set(value[:3], rest)
+		t, err := AddDuration(anchor, rest)
+		return t, relocate(err, value, 3)
+	}
+	return defaultParser.parseWithMap(layout, value, dict, loc, true, x)
+}
+
+// addAfterClock sets the wall clock time of anchor from an optional clock time at the start of rest,
+// such as "T15:30" or "3:30pm", then adds the duration in the remainder of rest to it. The anchor
+// is the prefix of value before rest.
+func addAfterClock(value string, anchor time.Time, rest string, x *Explanation) (time.Time, error) {
+	anchor, rest, err := applyClock(value, anchor, rest)
+	if err != nil {
+		return anchor, err
+	}
+	x.set(strings.TrimRight(value[:len(value)-len(rest)], whitespace), rest)
+	t, err := AddDuration(anchor, rest)
+	return t, relocate(err, value, len(value)-len(rest))
+}
+
+// Between parses a and b like ParseWithMap, additionally resolving the special string `now` as
+// ParseNow does, and returns the duration from a to b. Both values are resolved against the same
+// instant, so Between("", "now-1h", "now", nil) is exactly one hour, regardless of how much time
+// elapses between parsing the two values.
+func Between(layout, a, b string, dict map[string]time.Time) (time.Duration, error) {
+	now := time.Now()
+	ta, err := parseNow(now, layout, a, dict, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	tb, err := parseNow(now, layout, b, dict, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return tb.Sub(ta), nil
+}
+
+// ParseTolerance parses a value of the form "ANCHOR±DURATION", such as "now±5m", and returns the
+// window from the anchor minus the duration to the anchor plus the duration. The anchor is parsed
+// like ParseNow, and the duration after the '±' (U+00B1) is parsed like AddDuration, and applied in
+// both directions. It returns an error when value has no '±' or more than one, or when the duration
+// is negative, as in "now±-5m".
+func ParseTolerance(layout, value string) (start, end time.Time, err error) {
+	const plusMinus = "±"
+	i := strings.Index(value, plusMinus)
+	if i < 0 {
+		return time.Time{}, time.Time{}, fmt.Errorf("cannot parse tolerance: missing '±': %q", value)
+	}
+	rest := value[i+len(plusMinus):]
+	if strings.Contains(rest, plusMinus) {
+		return time.Time{}, time.Time{}, fmt.Errorf("cannot parse tolerance: multiple '±': %q", value)
+	}
+	if strings.TrimLeft(rest, whitespace) == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("cannot parse tolerance: missing duration after '±': %q", value)
+	}
+	anchor, err := ParseNow(layout, strings.TrimRight(value[:i], whitespace))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	o, err := Decompose(rest)
+	if err != nil {
+		return time.Time{}, time.Time{}, relocate(err, value, i+len(plusMinus))
+	}
+	negative := Offset{Years: -o.Years, Months: -o.Months, Days: -o.Days, Duration: -o.Duration}
+	start, end = negative.Apply(anchor), o.Apply(anchor)
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("cannot parse tolerance: negative duration after '±': %q", value)
+	}
+	return start, end, nil
+}
+
+// ParseList parses a comma separated list of values, each like Between does, ignoring whitespace
+// around each value. Values relative to `now` are all resolved against the same instant, so
+// "now, now+1h, now+2h" are exactly an hour apart. When a value is empty or cannot be parsed, the
+// error includes its zero based index in the list, and wraps any error from parsing it.
+func ParseList(layout, value string, dict map[string]time.Time) ([]time.Time, error) {
+	now := time.Now()
+	elements := strings.Split(value, ",")
+	times := make([]time.Time, len(elements))
+	for i, element := range elements {
+		element = strings.Trim(element, whitespace)
+		if element == "" {
+			return nil, fmt.Errorf("cannot parse list element %d: empty value", i)
+		}
+		t, err := parseNow(now, layout, element, dict, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse list element %d: %w", i, err)
+		}
+		times[i] = t
+	}
+	return times, nil
+}
+
+// ParseChain parses each expression like Between does, in order, with the special key "prev" in
+// each resolving to the result of the expression before it, so "now", "prev+1h", "prev+1h" are an
+// hour apart. The key "prev" takes precedence over a key of the same name in dict, and the first
+// expression must not use it. Expressions relative to `now` are all resolved against the same
+// instant. When an expression cannot be parsed, the error includes its zero based index, and wraps
+// the error from parsing it.
+func ParseChain(layout string, exprs []string, dict map[string]time.Time) ([]time.Time, error) {
+	now := time.Now()
+	chained := make(map[string]time.Time, len(dict)+1)
+	for k, v := range dict {
+		chained[k] = v
+	}
+	// A placeholder, so that the first expression using "prev" is found the same way as it would
+	// be resolved, even when dict has a longer key starting with "prev".
+	chained["prev"] = time.Time{}
+	times := make([]time.Time, len(exprs))
+	for i, expr := range exprs {
+		var x Explanation
+		t, err := parseNow(now, layout, expr, chained, nil, &x)
+		if i == 0 && x.Anchor == "prev" {
+			return nil, errors.New(`cannot parse chain element 0: "prev" has no previous element`)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse chain element %d: %w", i, err)
+		}
+		times[i] = t
+		chained["prev"] = t
+	}
+	return times, nil
+}
+
+// maxSeriesLength is the most times ParseSeries returns, which bounds the memory used by a series
+// with a step that is small relative to its range.
+const maxSeriesLength = 1000000
+
+// ParseSeries parses a series written as "start:end:step", such as "now-7d:now:1d", and returns the
+// times from start through end, inclusive, step apart. The start and end are parsed like Between
+// does, against the same instant, and may contain colons themselves, as RFC 3339 times do. The step
+// is a duration string like AddDuration accepts, and each time is the previous one plus the step,
+// so a step of "1mo" follows the calendar. The step must be positive, and the series must have no
+// more than one million times.
+func ParseSeries(layout, value string, dict map[string]time.Time) ([]time.Time, error) {
+	i := strings.LastIndexByte(value, ':')
+	if i < 0 {
+		return nil, fmt.Errorf("cannot parse series: expected start:end:step: %q", value)
+	}
+	step, err := Decompose(value[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse series step: %w", err)
+	}
+	now := time.Now()
+	bounds := value[:i]
+	// Try each colon in turn to split start from end, reporting the first error when none works.
+	var start, end time.Time
+	var firstErr error
+	for j := 0; ; j++ {
+		k := strings.IndexByte(bounds[j:], ':')
+		if k < 0 {
+			if firstErr != nil {
+				return nil, fmt.Errorf("cannot parse series: %w", firstErr)
+			}
+			return nil, fmt.Errorf("cannot parse series: expected start:end:step: %q", value)
+		}
+		j += k
+		if start, err = parseNow(now, layout, bounds[:j], dict, nil, nil); err == nil {
+			if end, err = parseNow(now, layout, bounds[j+1:], dict, nil, nil); err == nil {
+				break
+			}
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("cannot parse series: end %s is before start %s", end, start)
+	}
+	if next := step.Apply(start); !next.After(start) {
+		return nil, fmt.Errorf("cannot parse series: step must be positive: %q", value[i+1:])
+	}
+	var times []time.Time
+	for t := start; !t.After(end); t = step.Apply(t) {
+		if len(times) == maxSeriesLength {
+			return nil, fmt.Errorf("cannot parse series: more than %d times", maxSeriesLength)
+		}
+		times = append(times, t)
+	}
+	return times, nil
+}
+
+// InPast parses value like Between does, and reports whether the result is before the current
+// time. A value relative to `now` is compared with the same instant it was resolved against, so
+// "now-1ns" is always in the past and "now" never is.
+func InPast(layout, value string, dict map[string]time.Time) (bool, time.Time, error) {
+	now := time.Now()
+	t, err := parseNow(now, layout, value, dict, nil, nil)
+	if err != nil {
+		return false, t, err
+	}
+	return t.Before(now), t, nil
+}
+
+// InFuture is like InPast, but reports whether the result is after the current time.
+func InFuture(layout, value string, dict map[string]time.Time) (bool, time.Time, error) {
+	now := time.Now()
+	t, err := parseNow(now, layout, value, dict, nil, nil)
+	if err != nil {
+		return false, t, err
+	}
+	return t.After(now), t, nil
+}
+
+// ParseNowBounded parses value like ParseNow, but returns an error when the result is more than
+// maxOffset before or after the current time it was resolved against, for instance to bound the
+// cost of queries built from untrusted input. Because months and years are resolved against the
+// current time, the bound applies to the actual span they cover.
+func ParseNowBounded(layout, value string, maxOffset time.Duration) (time.Time, error) {
+	now := time.Now()
+	t, err := parseNow(now, layout, value, nil, nil, nil)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if offset := t.Sub(now); offset > maxOffset || offset < -maxOffset {
+		return time.Time{}, fmt.Errorf("cannot parse %q: offset of %s from now exceeds maximum of %s", value, offset, maxOffset)
+	}
+	return t, nil
+}
+
+// MustParse is like Parse but panics if the value cannot be parsed. It simplifies safe
+// initialization of global variables holding time values.
+func MustParse(layout, value string) time.Time {
+	t, err := Parse(layout, value)
+	if err != nil {
+		panic(`tparse: Parse(` + strconv.Quote(value) + `): ` + err.Error())
+	}
+	return t
+}
+
+// MustParseNow is like ParseNow but panics if the value cannot be parsed. It simplifies safe
+// initialization of global variables holding time values.
+func MustParseNow(layout, value string) time.Time {
+	t, err := ParseNow(layout, value)
+	if err != nil {
+		panic(`tparse: ParseNow(` + strconv.Quote(value) + `): ` + err.Error())
+	}
+	return t
 }
 
 // ParseWithMap will return the time value corresponding to the specified layout and value.  It also
-// parses floating point and integer epoch values.  It accepts a map of strings to time.Time values,
+// parses floating point and integer epoch values, and integer epoch values with an explicit unit
+// suffix of "s", "ms", "us", or "ns", such as "1609459200000ms".  A value that does not match layout
+// may also be an epoch value immediately followed by a signed duration, such as "1609459200+1h".  It
+// accepts a map of strings to time.Time values,
 // and if the value string starts with one of the keys in the map, it replaces the string with the
 // corresponding time.Time value.
 //
+// A value starting with the special string `epoch` is relative to the Unix epoch, in UTC, so
+// "epoch+1d" is midnight on January 2, 1970. A key in the map named "epoch" takes precedence.
+//
+// A value that does not match layout may also be a time that does, immediately followed by a signed
+// duration, such as "2021-01-01+3mo" with the layout "2006-01-02" for three months after that date.
+// This is tried before an epoch value followed by a duration, so with the layout "20060102",
+// "20210101-3d" is three days before 2021, rather than three days before an epoch value in 1970.
+//
+// A value starting with '@', as accepted by GNU date, is always an epoch value, such as
+// "@1609459200" or "@1609459200.5", even when layout would also match the number after it.
+//
+// Keys are only matched at the start of the value, before any other interpretation, and when
+// several keys match, the longest one is used. The remainder of the value after the key is always
+// parsed as a duration. So with keys "start" and "start_of_day", the value "start_of_day+1h" is
+// relative to "start_of_day", and a key such as "s" that is also a unit token is never confused
+// with that unit: "s+1s" is one second after the time of key "s".
+//
+// The choice of key is deterministic, despite the random iteration order of maps. Two keys that are
+// both prefixes of a value are either equal or of different lengths, so there is never a tie
+// between keys of the same length to break.
+//
 //     package main
 //
 //     import (
@@ -274,27 +1193,467 @@ func ParseWithMap(layout, value string, dict map[string]time.Time) (time.Time, e
 }
 
 func ParseWithMapInLocation(layout, value string, dict map[string]time.Time, loc *time.Location) (time.Time, error) {
-	// find longest matching key in dict
+	return defaultParser.parseWithMap(layout, value, dict, loc, true, nil)
+}
+
+// ParseNoEpoch is like ParseWithMap, but never interprets the value as a floating point or integer
+// epoch value. A value that is neither relative to a key in dict nor valid for layout, including a
+// bare number, returns the error from time.Parse. The explicit forms, such as "epoch+1d" and
+// "@1609459200", are still accepted.
+func ParseNoEpoch(layout, value string, dict map[string]time.Time) (time.Time, error) {
+	return defaultParser.parseWithMap(layout, value, dict, nil, false, nil)
+}
+
+// ParseClamped is like ParseWithMap, but clamps the result into the window from min to max,
+// inclusive. It returns true when the parsed time was outside the window and had to be clamped. It
+// returns an error when min is after max.
+func ParseClamped(layout, value string, dict map[string]time.Time, min, max time.Time) (time.Time, bool, error) {
+	if min.After(max) {
+		return time.Time{}, false, fmt.Errorf("cannot clamp to window: minimum %s is after maximum %s", min, max)
+	}
+	t, err := ParseWithMap(layout, value, dict)
+	if err != nil {
+		return t, false, err
+	}
+	if t.Before(min) {
+		return min, true, nil
+	}
+	if t.After(max) {
+		return max, true, nil
+	}
+	return t, false, nil
+}
+
+// ParseInZoneName parses a value that ends with an IANA time zone name separated from the rest of
+// the value by whitespace, such as "2006-01-02 15:04:05 America/New_York". It loads the named
+// location using time.LoadLocation, then parses the remainder of the value using layout in that
+// location. A trailing field is treated as a zone name when it is "UTC", "Local", or contains a
+// slash. When the value has no trailing zone name, it is parsed by Parse.
+func ParseInZoneName(layout, value string) (time.Time, error) {
+	trimmed := strings.TrimRight(value, whitespace)
+	i := strings.LastIndexAny(trimmed, whitespace)
+	if i < 0 {
+		return Parse(layout, value)
+	}
+	name := trimmed[i+1:]
+	if name != "UTC" && name != "Local" && !strings.Contains(name, "/") {
+		return Parse(layout, value)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.ParseInLocation(layout, strings.TrimRight(trimmed[:i], whitespace), loc)
+}
+
+// ParseBasicISO8601 parses a time in the basic format of ISO 8601, which omits the separators of the
+// extended format used by RFC 3339, such as "20060102T150405Z" or "20060102T150405-0700". A value
+// without a zone designator is in UTC.
+func ParseBasicISO8601(value string) (time.Time, error) {
+	const layout = "20060102T150405"
+	if len(value) < len(layout) || value[8] != 'T' {
+		return time.Time{}, fmt.Errorf("cannot parse basic ISO 8601 time: %q", value)
+	}
+	for i := 0; i < len(layout); i++ {
+		if c := value[i]; i != 8 && (c < '0' || c > '9') {
+			return time.Time{}, fmt.Errorf("cannot parse basic ISO 8601 time: non-digit in date or time: %q", value)
+		}
+	}
+	switch zone := value[len(layout):]; {
+	case zone == "":
+		return time.Parse(layout, value)
+	case zone == "Z":
+		return time.Parse(layout+"Z", value)
+	case len(zone) == 5 && (zone[0] == '+' || zone[0] == '-'):
+		return time.Parse(layout+"-0700", value)
+	default:
+		return time.Time{}, fmt.Errorf("cannot parse basic ISO 8601 time: invalid zone designator: %q", value)
+	}
+}
+
+// ParseISOWeekDate parses an ISO 8601 week date, such as "2021-W05-3" for the Wednesday of the fifth
+// week of 2021, and returns midnight UTC of that day. Weeks start on Monday, which is day 1, and the
+// first week of a year is the one containing its first Thursday, so a week date may fall in the
+// previous or next calendar year. The week must be from 01 through 53, and week 53 must exist in the
+// year.
+func ParseISOWeekDate(value string) (time.Time, error) {
+	if len(value) != 10 || value[4] != '-' || value[5] != 'W' || value[8] != '-' {
+		return time.Time{}, fmt.Errorf("cannot parse ISO week date: expected YYYY-Www-D: %q", value)
+	}
+	var fields [3]int
+	for i, field := range []string{value[:4], value[6:8], value[9:]} {
+		for j := 0; j < len(field); j++ {
+			c := field[j]
+			if c < '0' || c > '9' {
+				return time.Time{}, fmt.Errorf("cannot parse ISO week date: non-digit in year, week, or day: %q", value)
+			}
+			fields[i] = 10*fields[i] + int(c-'0')
+		}
+	}
+	year, week, day := fields[0], fields[1], fields[2]
+	if week < 1 || week > 53 {
+		return time.Time{}, fmt.Errorf("cannot parse ISO week date: week out of range: %q", value)
+	}
+	if day < 1 || day > 7 {
+		return time.Time{}, fmt.Errorf("cannot parse ISO week date: day out of range: %q", value)
+	}
+	// January 4th is always in the first week.
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday()) + 6) % 7))
+	t := monday.AddDate(0, 0, 7*(week-1)+day-1)
+	if y, w := t.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("cannot parse ISO week date: year %d has no week %d: %q", year, week, value)
+	}
+	return t, nil
+}
+
+// httpDateLayouts are the layouts tried by ParseHTTPDate, in order.
+var httpDateLayouts = []string{time.RFC1123, time.RFC1123Z, time.RFC822, time.RFC822Z}
+
+// httpDateZones maps the zone abbreviations recognized by ParseHTTPDate to their offsets east of UTC
+// in seconds.
+var httpDateZones = map[string]int{
+	"GMT":  0,
+	"UT":   0,
+	"UTC":  0,
+	"Z":    0,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"CST":  -6 * 3600,
+	"CDT":  -5 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"AST":  -4 * 3600,
+	"ADT":  -3 * 3600,
+	"AKST": -9 * 3600,
+	"AKDT": -8 * 3600,
+	"HST":  -10 * 3600,
+	"NST":  -(3*3600 + 1800),
+	"NDT":  -(2*3600 + 1800),
+}
+
+// ParseHTTPDate parses a date as found in HTTP and email headers, in the RFC 1123 or RFC 822 format,
+// with either a numeric zone offset or a zone abbreviation, such as "Tue, 10 Nov 2009 23:00:00 GMT"
+// or "10 Nov 09 18:00 EST". Unlike time.Parse, which gives an abbreviation it does not know from the
+// local location an offset of zero, the North American abbreviations of RFC 822, such as "EST" and
+// "PDT", and a few others, such as "AKST" and "HST", always have their standard offsets. Other
+// abbreviations are rejected, because their offsets are ambiguous.
+func ParseHTTPDate(value string) (time.Time, error) {
+	for _, layout := range httpDateLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+		if layout == time.RFC1123Z || layout == time.RFC822Z {
+			return t, nil
+		}
+		name, _ := t.Zone()
+		offset, ok := httpDateZones[name]
+		if !ok {
+			return time.Time{}, fmt.Errorf("cannot parse HTTP date: unknown zone abbreviation %q: %q", name, value)
+		}
+		y, m, d := t.Date()
+		return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, offset)), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse HTTP date: %q", value)
+}
+
+// flexibleDateLayouts are the layouts tried by ParseFlexibleDate, in order.
+var flexibleDateLayouts = []string{
+	"2 Jan 2006",
+	"2 January 2006",
+	"2-Jan-2006",
+	"2-January-2006",
+	"Jan 2 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"Mon 2 Jan 2006",
+	"Monday 2 January 2006",
+	"Mon, 2 Jan 2006",
+	"Monday, 2 January 2006",
+	"Mon Jan 2 2006",
+	"Monday January 2 2006",
+	"Mon, Jan 2, 2006",
+	"Monday, January 2, 2006",
+}
+
+// ParseFlexibleDate parses a date written the way people commonly write them, with a short or long
+// English month name, the day before or after the month, and an optional leading day of the week,
+// such as "2 Jan 2006", "2 January 2006", "January 2, 2006", or "Monday, 2 January 2006". The layout
+// of the first match is used, and the date is at midnight UTC. A day of the week is accepted but not
+// checked against the date.
+func ParseFlexibleDate(value string) (time.Time, error) {
+	for _, layout := range flexibleDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date: %q", value)
+}
+
+// longestKey returns the longest key in dict that value starts with, or the empty string when there
+// is none. Distinct prefixes of the same value have distinct lengths, so the result does not depend
+// on map iteration order.
+func longestKey(value string, dict map[string]time.Time) string {
 	var matchKey string
 	for k := range dict {
 		if strings.HasPrefix(value, k) && len(k) > len(matchKey) {
 			matchKey = k
 		}
 	}
-	if len(matchKey) > 0 {
-		return AddDuration(dict[matchKey], value[len(matchKey):])
+	return matchKey
+}
+
+// parseWithMap parses value relative to the longest matching keyword of the Parser, then relative
+// to the longest matching key in dict, then as a calendar anchor such as "bow" relative to the "now"
+// keyword when the Parser has one, then relative to the "epoch" keyword, then as an explicit epoch
+// value prefixed by '@', then as an epoch value when epoch is true and loc is nil, unless
+// LayoutFirst is set and the value matches a non-empty layout, then using layout, then as a time
+// matching layout followed by a duration, and finally as an epoch value followed by a duration when
+// epoch is true and loc is nil. Durations are parsed using the options of the Parser. When x is
+// not nil, it records how value was resolved.
+func (p *Parser) parseWithMap(layout, value string, dict map[string]time.Time, loc *time.Location, epoch bool, x *Explanation) (time.Time, error) {
+	// Find longest matching keyword. Distinct prefixes of the same value have distinct lengths,
+	// so the result does not depend on map iteration order.
+	var keyword string
+	for k := range p.Keywords {
+		if strings.HasPrefix(value, k) && len(k) > len(keyword) {
+			keyword = k
+		}
+	}
+	if len(keyword) > 0 {
+		x.set(keyword, value[len(keyword):])
+		t, err := p.AddDuration(p.keywordTime(keyword), value[len(keyword):])
+		return t, relocate(err, value, len(keyword))
+	}
+
+	if matchKey := longestKey(value, dict); len(matchKey) > 0 {
+		x.set(matchKey, value[len(matchKey):])
+		t, err := p.AddDuration(dict[matchKey], value[len(matchKey):])
+		return t, relocate(err, value, len(matchKey))
+	}
+
+	if _, ok := p.Keywords["now"]; ok {
+		if anchor, rest, ok := calendarAnchor(p.keywordTime("now"), value, p.weekStart()); ok {
+			x.set(value[:3], rest)
+			t, err := p.AddDuration(anchor, rest)
+			return t, relocate(err, value, 3)
+		}
+	}
+
+	if strings.HasPrefix(value, "epoch") {
+		x.set("epoch", value[5:])
+		t, err := p.AddDuration(time.Unix(0, 0).UTC(), value[5:])
+		return t, relocate(err, value, 5)
+	}
+
+	if strings.HasPrefix(value, "@") {
+		x.set("epoch", "")
+		if rest := value[1:]; mayBeEpoch(rest) {
+			if t, ok := parseUnitEpoch(rest); ok {
+				return t, nil
+			}
+			if epoch, ok := parseEpoch(rest); ok {
+				return epochTime(epoch), nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q: expected a non-negative epoch value after '@'", value)
 	}
 
 	if loc != nil {
-		return time.ParseInLocation(layout, value, loc)
+		t, err := time.ParseInLocation(layout, value, loc)
+		if err != nil {
+			if t, rest, ok := p.layoutOffset(layout, value, loc); ok {
+				x.set("layout", rest)
+				return t, nil
+			}
+		}
+		x.set("layout", "")
+		return t, err
+	}
+
+	// takes about 90ns even if fails, so only attempt when value might be a number
+	if epoch && mayBeEpoch(value) {
+		if p.LayoutFirst && layout != "" {
+			if t, err := time.Parse(layout, value); err == nil {
+				x.set("layout", "")
+				return t, nil
+			}
+		}
+		number := stripDigitSeparators(value)
+		if t, ok := parseUnitEpoch(number); ok {
+			x.set("epoch", "")
+			return t, nil
+		}
+		if epoch, ok := parseEpoch(number); ok {
+			x.set("epoch", "")
+			return epochTime(epoch), nil
+		}
+	}
+
+	t, err := time.Parse(layout, value)
+	if err != nil {
+		// Only after the layout fails, so values such as "2006-01-02" are never split as a time
+		// followed by a duration. A time matching layout is tried before an epoch value, so with the
+		// layout "20060102", "20210101-3d" is three days before 2021.
+		if t, rest, ok := p.layoutOffset(layout, value, nil); ok {
+			x.set("layout", rest)
+			return t, nil
+		}
+	}
+	if err != nil && epoch {
+		if i := epochOffsetIndex(value); i > 0 {
+			if base, ok := parseEpoch(value[:i]); ok {
+				if t, derr := p.AddDuration(epochTime(base), value[i:]); derr == nil {
+					x.set("epoch", value[i:])
+					return t, nil
+				}
+			}
+		}
+	}
+	x.set("layout", "")
+	return t, err
+}
+
+// layoutOffset parses value as a time matching layout immediately followed by a signed duration,
+// such as "2021-01-01+3mo" for the layout "2006-01-02", in loc when it is not nil, and in UTC
+// otherwise. Each sign in value is tried in turn as the start of the duration, so signs within the
+// time itself, such as those of a date or a zone offset, are skipped over. It returns the time
+// with the duration added, and the duration, or false when no sign splits value into a valid time
+// and a valid duration.
+func (p *Parser) layoutOffset(layout, value string, loc *time.Location) (time.Time, string, bool) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	for i := 1; i < len(value); i++ {
+		if c := value[i]; c != '+' && c != '-' {
+			continue
+		}
+		base, err := time.ParseInLocation(layout, value[:i], loc)
+		if err != nil {
+			continue
+		}
+		if t, err := p.AddDuration(base, value[i:]); err == nil {
+			return t, value[i:], true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// parseEpoch parses value as a floating point epoch value, and returns false unless it is a
+// non-negative number of seconds that time.Unix can represent, so that values such as "+Inf" and
+// "1e300" are rejected rather than wrapped around.
+func parseEpoch(value string) (float64, bool) {
+	epoch, err := strconv.ParseFloat(value, 64)
+	return epoch, err == nil && epoch >= 0 && epoch < math.MaxInt64
+}
+
+// epochTime returns the time corresponding to the non-negative floating point epoch value.
+func epochTime(epoch float64) time.Time {
+	trunc := math.Trunc(epoch)
+	nanos := fractionToNanos(epoch - trunc)
+	return time.Unix(int64(trunc), int64(nanos))
+}
+
+// epochOffsetIndex returns the index of the sign that ends a leading decimal epoch value, such as
+// the second '+' in "+1609459200+1h", or -1 when value does not start with an epoch value
+// immediately followed by a sign. Like a bare epoch value, the leading epoch value may have a plus
+// sign.
+func epochOffsetIndex(value string) int {
+	var digits, points int
+	var i int
+	if strings.HasPrefix(value, "+") {
+		i++
+	}
+	for ; i < len(value); i++ {
+		switch c := value[i]; {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && points == 0:
+			points++
+		case (c == '+' || c == '-') && digits > 0:
+			return i
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// parseUnitEpoch parses an epoch value made of only decimal digits followed by one of the unit
+// suffixes "s", "ms", "us", or "ns", returning the time that many units after the Unix epoch. It
+// returns false when value is not of that form, so that a signed value such as "+1s" is never
+// mistaken for an epoch.
+func parseUnitEpoch(value string) (time.Time, bool) {
+	var i int
+	for i < len(value) && value[i] >= '0' && value[i] <= '9' {
+		i++
+	}
+	if i == 0 {
+		return time.Time{}, false
+	}
+	var perSecond int64
+	switch value[i:] {
+	case "s":
+		perSecond = 1
+	case "ms":
+		perSecond = 1e3
+	case "us":
+		perSecond = 1e6
+	case "ns":
+		perSecond = 1e9
+	default:
+		return time.Time{}, false
 	}
+	n, err := strconv.ParseInt(value[:i], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(n/perSecond, (n%perSecond)*(int64(time.Second)/perSecond)), true
+}
 
-	// takes about 90ns even if fails
-	if epoch, err := strconv.ParseFloat(value, 64); err == nil && epoch >= 0 {
-		trunc := math.Trunc(epoch)
-		nanos := fractionToNanos(epoch - trunc)
-		return time.Unix(int64(trunc), int64(nanos)), nil
+// stripDigitSeparators returns value without the underscores that separate groups of digits, as in
+// the Go literal 1_609_459_200. Only an underscore with a digit on both sides is removed, so a
+// leading, trailing, or doubled underscore remains, and the value fails to parse as a number. It
+// returns value when it has no underscore.
+func stripDigitSeparators(value string) string {
+	if strings.IndexByte(value, '_') < 0 {
+		return value
 	}
+	isDigit := func(i int) bool { return i >= 0 && i < len(value) && value[i] >= '0' && value[i] <= '9' }
+	b := make([]byte, 0, len(value))
+	for i := 0; i < len(value); i++ {
+		if value[i] == '_' && isDigit(i-1) && isDigit(i+1) {
+			continue
+		}
+		b = append(b, value[i])
+	}
+	return string(b)
+}
 
-	return time.Parse(layout, value)
+// mayBeEpoch returns false when value clearly cannot be parsed as a non-negative floating point
+// number, allowing callers to avoid the cost of a failed strconv.ParseFloat. A value that may be an
+// epoch begins with a digit, a decimal point, or a plus sign, and has no sign after its first byte
+// except as part of an exponent.
+func mayBeEpoch(value string) bool {
+	if value == "" {
+		return false
+	}
+	if c := value[0]; c != '+' && c != '.' && (c < '0' || c > '9') {
+		return false
+	}
+	for i := 1; i < len(value); i++ {
+		if c := value[i]; c == '+' || c == '-' {
+			switch value[i-1] {
+			case 'e', 'E', 'p', 'P':
+				// exponent sign: no-op
+			default:
+				return false
+			}
+		}
+	}
+	return true
 }
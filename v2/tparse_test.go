@@ -1,7 +1,12 @@
 package tparse
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"testing"
 	"time"
 )
@@ -151,163 +156,1815 @@ func TestAddDurationNegativeFractionalDay(t *testing.T) {
 	}
 }
 
-func TestAddDurationMissignUnits(t *testing.T) {
-	t.Run("zero", func(t *testing.T) {
-		_, err := AddDuration(time.Now(), "0")
-		ensureError(t, err, "duration missing units")
-	})
+func TestAddDurationNegativeFractions(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 
-	t.Run("plus zero", func(t *testing.T) {
-		_, err := AddDuration(time.Now(), "+0")
-		ensureError(t, err, "duration missing units")
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"-2.5weeks", base.Add(-17*24*time.Hour - 12*time.Hour)},
+		{"-2.5w", base.Add(-17*24*time.Hour - 12*time.Hour)},
+		{"-2.5days", base.Add(-60 * time.Hour)},
+		{"-2.5d", base.Add(-60 * time.Hour)},
+		{"-2.5months", base.AddDate(0, -2, -15)},
+		{"-2.5mo", base.AddDate(0, -2, -15)},
+		{"-2.5years", base.AddDate(-2, -6, 0)},
+		{"-2.5y", base.AddDate(-2, -6, 0)},
+		{"-2.5ms", base.Add(-2500 * time.Microsecond)},
+		{"-2.5µs", base.Add(-2500 * time.Nanosecond)},
+		{"-0.5h", base.Add(-30 * time.Minute)},
+		{"2.5mo ago", base.AddDate(0, -2, -15)},
+		{"1h-2.5m", base.Add(time.Hour - 150*time.Second)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := AddDuration(base, c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+}
+
+func TestAddDurationZeroWithoutUnit(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{"0", "+0", "-0", "0.0", "1h+0-1h", "+0+1h-1h"} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := AddDuration(base, value)
+			ensureError(t, err)
+			if actual != base {
+				t.Errorf("Actual: %s; Expected: %s", actual, base)
+			}
+		})
+	}
+
+	for _, value := range []string{"now+0", "now-0"} {
+		t.Run(value, func(t *testing.T) {
+			before := time.Now()
+			actual, err := ParseNow("", value)
+			ensureError(t, err)
+			after := time.Now()
+			if before.After(actual) || actual.After(after) {
+				t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+			}
+		})
+	}
+
+	t.Run("followed by number", func(t *testing.T) {
+		_, err := AddDuration(base, "0 5h")
+		ensureError(t, err, `missing unit after number "0"`)
 	})
+}
 
-	t.Run("minus zero", func(t *testing.T) {
-		_, err := AddDuration(time.Now(), "-0")
-		ensureError(t, err, "duration missing units")
+func TestAddDurationMissignUnits(t *testing.T) {
+	t.Run("now plus five", func(t *testing.T) {
+		_, err := ParseNow("", "now+5")
+		ensureError(t, err, `missing unit after number "5"`)
 	})
 
 	t.Run("one", func(t *testing.T) {
 		_, err := AddDuration(time.Now(), "1")
-		ensureError(t, err, "duration missing units")
+		ensureError(t, err, `missing unit after number "1"`)
 	})
 
 	t.Run("float", func(t *testing.T) {
 		_, err := AddDuration(time.Now(), "12.3")
-		ensureError(t, err, "duration missing units")
+		ensureError(t, err, `missing unit after number "12.3"`)
+	})
+
+	t.Run("twenty", func(t *testing.T) {
+		_, err := AddDuration(time.Now(), "20")
+		ensureError(t, err, `missing unit after number "20"`)
+	})
+
+	t.Run("now plus twenty", func(t *testing.T) {
+		_, err := ParseNow("", "now+20")
+		ensureError(t, err, `missing unit after number "20"`)
+	})
+
+	t.Run("before sign", func(t *testing.T) {
+		_, err := AddDuration(time.Now(), "1h+5")
+		ensureError(t, err, `missing unit after number "5"`)
 	})
 }
 
-// ParseWithMap
+func TestAddDurationStopwatch(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 
-func TestParseWithMapFloatingEpochPositive(t *testing.T) {
-	actual, err := ParseWithMap("", "1445535988.5", nil)
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"1h30", base.Add(90 * time.Minute)},
+		{"1h30m", base.Add(90 * time.Minute)},
+		{"1m30", base.Add(90 * time.Second)},
+		{"1m30s", base.Add(90 * time.Second)},
+		{"+2hours 15", base.Add(135 * time.Minute)},
+		{"1 hour 30 ", base.Add(90 * time.Minute)},
+		{"1h-15", base.Add(45 * time.Minute)},
+		{"-1h30", base.Add(-30 * time.Minute)},
+		{"1d2h30", base.Add(26*time.Hour + 30*time.Minute)},
+		{"1h2m30", base.Add(time.Hour + 2*time.Minute + 30*time.Second)},
+		{"1m7.5", base.Add(67500 * time.Millisecond)},
+		{"1h", base.Add(time.Hour)},
+		{"", base},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := AddDurationStopwatch(base, c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
 	}
 
-	nanos := int64(0.5 * float64(time.Second/time.Nanosecond))
+	t.Run("default rejects", func(t *testing.T) {
+		_, err := AddDuration(base, "1h30")
+		ensureError(t, err, `missing unit after number "30"`)
+	})
 
-	expected := time.Unix(1445535988, nanos)
-	if actual != expected {
-		t.Errorf("Actual: %s; Expected: %s", actual, expected)
-	}
+	t.Run("no smaller unit", func(t *testing.T) {
+		for _, value := range []string{"30", "1d30", "1s30", "1mo30"} {
+			_, err := AddDurationStopwatch(base, value)
+			ensureError(t, err, `missing unit after number "30"`)
+		}
+	})
+
+	t.Run("error in input as written", func(t *testing.T) {
+		_, err := AddDurationStopwatch(base, "1x1h30")
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Input != "1x1h30" || e.Offset != 1 {
+			t.Errorf("GOT: %#v; WANT: offset %d in %q", err, 1, "1x1h30")
+		}
+	})
 }
 
-func TestParseWithMapFloatingEpochNegative(t *testing.T) {
-	_, err := ParseWithMap("", "-1445535988.5", nil)
-	if _, ok := err.(*time.ParseError); err == nil || !ok {
-		t.Errorf("Actual: %#v; Expected: %s", err, "negative floating point not allowed")
-	}
+func TestAddDurationNumericOverflow(t *testing.T) {
+	t.Run("whole", func(t *testing.T) {
+		_, err := AddDuration(time.Now(), "9999999999999999999999999h")
+		ensureError(t, err, "numeric overflow in duration")
+	})
+
+	t.Run("fraction", func(t *testing.T) {
+		// Digits beyond the precision of a float64 are ignored rather than overflowing.
+		base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		actual, err := AddDuration(base, "1.9999999999999999999999999h")
+		ensureError(t, err)
+		if expected := base.Add(2 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
 }
 
-func TestParseWithMap(t *testing.T) {
-	before := time.Now().UTC()
-	dict := map[string]time.Time{
-		"start": time.Now().UTC().AddDate(0, 0, -7),
-	}
-	after := time.Now().UTC()
+func TestAddDurationTinyFraction(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 
-	actual, err := ParseWithMap(time.ANSIC, "start+1week", dict)
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"+0.0000000000000001ns", base},
+		{"-0.0000000000000001ns", base},
+		{"-0.0s", base},
+		{"-0h", base},
+		{"0." + strings.Repeat("0", 400) + "1y", base},
+		{"0." + strings.Repeat("9", 400) + "s", base.Add(time.Second)},
+		{"1." + strings.Repeat("5", 400) + "ns", base.Add(time.Nanosecond)},
+		{"0.5" + strings.Repeat("0", 400) + "s", base.Add(500 * time.Millisecond)},
+		{"1.25" + strings.Repeat("0", 400) + "h", base.Add(75 * time.Minute)},
 	}
-
-	actual = actual.UTC()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%.24s", c.value), func(t *testing.T) {
+			actual, err := AddDuration(base, c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
 	}
+
+	t.Run("decompose", func(t *testing.T) {
+		o, err := Decompose("0." + strings.Repeat("0", 400) + "1y")
+		ensureError(t, err)
+		for _, f := range []float64{o.Years, o.Months, o.Days} {
+			if math.IsNaN(f) || math.IsInf(f, 0) || f != 0 {
+				t.Errorf("Actual: %#v; Expected: zero offset", o)
+			}
+		}
+	})
 }
 
-// ParseNow
+func TestAddDurationDecimalPoint(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
 
-func TestParseNow(t *testing.T) {
-	before := time.Now()
-	actual, err := ParseNow("", "now")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
-	}
-	after := time.Now()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
-	}
+	t.Run("leading", func(t *testing.T) {
+		actual, err := AddDuration(base, ".5h")
+		ensureError(t, err)
+		if expected := base.Add(30 * time.Minute); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("trailing", func(t *testing.T) {
+		actual, err := AddDuration(base, "5.h")
+		ensureError(t, err)
+		if expected := base.Add(5 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("signed leading", func(t *testing.T) {
+		actual, err := AddDuration(base, "-.25d")
+		ensureError(t, err)
+		if expected := base.Add(-6 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("alone", func(t *testing.T) {
+		actual, err := AddDuration(base, ".h")
+		ensureError(t, err, "no digits found")
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+		if e, ok := err.(*ParseError); !ok || e.Offset != 0 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 0)
+		}
+	})
 }
 
-func TestParseNowMinusSecond(t *testing.T) {
-	before := time.Now().UTC().Add(-2 * time.Second)
-	actual, err := ParseNow("", "now-2second")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestAddDurationRejectsDecimalPointWithoutDigits(t *testing.T) {
+	for _, value := range []string{".", ".h", "+.", "1h-.m"} {
+		t.Run(value, func(t *testing.T) {
+			_, err := AddDuration(time.Now(), value)
+			ensureError(t, err, "no digits found")
+		})
 	}
-	after := time.Now().UTC().Add(-2 * time.Second)
-	actual = actual.UTC()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+}
+
+func TestAddDurationOverflow(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{"1000000000h", "-1000000000h", "1h+1000000000h", "200000d"} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := AddDuration(base, value)
+			ensureError(t, err, "duration overflow")
+			if actual != base {
+				t.Errorf("Actual: %s; Expected: %s", actual, base)
+			}
+		})
 	}
+
+	t.Run("offset", func(t *testing.T) {
+		_, err := AddDuration(base, "1h+1000000000h")
+		if e, ok := err.(*ParseError); !ok || e.Offset != 2 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 2)
+		}
+	})
+
+	t.Run("collapsed fractions", func(t *testing.T) {
+		actual, err := AddDuration(base, "2562047h0.01mo")
+		ensureError(t, err, "duration overflow")
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+
+		p := Parser{Calendar: true}
+		actual, err = p.AddDuration(base, "2562047h 0.5d")
+		ensureError(t, err, "duration overflow")
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+
+		_, err = ApplyUnits(base, map[string]float64{"h": 2562047, "mo": 0.01})
+		ensureError(t, err, "duration overflow")
+
+		_, err = AddDurationUnitFirst(base, "h2562047 mo0.01")
+		ensureError(t, err, "duration overflow")
+
+		_, err = Offset{Duration: 2562047 * time.Hour}.Add("0.01mo")
+		ensureError(t, err, "duration overflow")
+	})
+
+	t.Run("collapsed fractions in range", func(t *testing.T) {
+		actual, err := AddDuration(base, "2562040h0.01mo")
+		ensureError(t, err)
+		// A float64 near the limit of time.Duration is only precise to about a microsecond.
+		expected := base.Add(2562040*time.Hour + 7*time.Hour + 12*time.Minute)
+		if d := actual.Sub(expected); d < -time.Millisecond || d > time.Millisecond {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("years are not limited", func(t *testing.T) {
+		actual, err := AddDuration(base, "+500y")
+		ensureError(t, err)
+		if expected := base.AddDate(500, 0, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
 }
 
-func TestParseNowMinusMillisecond(t *testing.T) {
-	before := time.Now()
-	time.Sleep(10 * time.Millisecond)
-	actual, err := ParseNow("", "now-10ms")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestParseDuration(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		actual, err := ParseDuration("1d2h30m")
+		ensureError(t, err)
+		if expected := 26*time.Hour + 30*time.Minute; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		actual, err := ParseDuration("")
+		ensureError(t, err)
+		if actual != 0 {
+			t.Errorf("Actual: %s; Expected: %s", actual, time.Duration(0))
+		}
+	})
+
+	t.Run("calendar units", func(t *testing.T) {
+		_, err := ParseDuration("1mo")
+		ensureError(t, err, "months and years have no fixed length")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := ParseDuration("1x")
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestTimeout(t *testing.T) {
+	cases := map[string]time.Duration{
+		"30s":   30 * time.Second,
+		"1m30s": 90 * time.Second,
+		"250ms": 250 * time.Millisecond,
+		"1d":    24 * time.Hour,
+		"2w":    14 * 24 * time.Hour,
 	}
-	after := time.Now()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	for value, expected := range cases {
+		actual, err := Timeout(value)
+		ensureError(t, err)
+		if actual != expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+		}
 	}
+
+	t.Run("months", func(t *testing.T) {
+		_, err := Timeout("1mo")
+		ensureError(t, err, `cannot use "1mo" as a timeout: months and years vary in length`)
+	})
+
+	t.Run("years", func(t *testing.T) {
+		_, err := Timeout("1h1y")
+		ensureError(t, err, "months and years vary in length")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Timeout("30x")
+		ensureError(t, err, `unknown unit "x"`)
+	})
 }
 
-func TestParseNowPlusMillisecond(t *testing.T) {
-	before := time.Now()
-	actual, err := ParseNow("", "now+10ms")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestParseDurationStrict(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		_, err := ParseDurationStrict("")
+		ensureError(t, err, "empty duration")
+	})
+
+	t.Run("whitespace", func(t *testing.T) {
+		_, err := ParseDurationStrict(" \t ")
+		ensureError(t, err, "empty duration")
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		actual, err := ParseDurationStrict("-90s")
+		ensureError(t, err)
+		if expected := -90 * time.Second; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestAddDurations(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	times, errs := AddDurations(base, []string{"+1h", "1x", "-30m", "", "1.2.3h"})
+
+	expected := []time.Time{base.Add(time.Hour), base, base.Add(-30 * time.Minute), base, base}
+	if len(times) != len(expected) || len(errs) != len(expected) {
+		t.Fatalf("GOT: %d times and %d errors; WANT: %d of each", len(times), len(errs), len(expected))
 	}
-	time.Sleep(10 * time.Millisecond)
-	after := time.Now()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	for i := range expected {
+		if times[i] != expected[i] {
+			t.Errorf("Index: %d; Actual: %s; Expected: %s", i, times[i], expected[i])
+		}
 	}
+	ensureError(t, errs[0])
+	ensureError(t, errs[1], `unknown unit "x"`)
+	ensureError(t, errs[2])
+	ensureError(t, errs[3])
+	ensureError(t, errs[4], "two decimal points")
+
+	t.Run("empty", func(t *testing.T) {
+		times, errs := AddDurations(base, nil)
+		if len(times) != 0 || len(errs) != 0 {
+			t.Errorf("GOT: %v, %v; WANT: empty slices", times, errs)
+		}
+	})
 }
 
-func TestParseNowPlusQuarterDay(t *testing.T) {
-	before := time.Now().UTC().Add(6 * time.Hour)
-	actual, err := ParseNow("", "now+0.25day")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestApplyUnits(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		units    map[string]float64
+		duration string
+	}{
+		{map[string]float64{"h": 1.5, "mo": 2}, "1.5h2mo"},
+		{map[string]float64{"y": 1.5, "d": -2.25}, "1.5y-2.25d"},
+		{map[string]float64{"weeks": 1, "minutes": 30, "ms": 250}, "1weeks30minutes250ms"},
+		{map[string]float64{"mo": 0.5}, "0.5mo"},
+		{nil, ""},
 	}
-	after := time.Now().UTC().Add(6 * time.Hour)
-	actual = actual.UTC()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+
+	for _, c := range cases {
+		t.Run(c.duration, func(t *testing.T) {
+			actual, err := ApplyUnits(base, c.units)
+			ensureError(t, err)
+			expected, err := AddDuration(base, c.duration)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
 	}
+
+	t.Run("unknown unit", func(t *testing.T) {
+		actual, err := ApplyUnits(base, map[string]float64{"h": 1, "dya": 2, "x": 3})
+		ensureError(t, err, `unknown unit "dya"; did you mean "day"?`)
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+	})
+
+	t.Run("empty unit", func(t *testing.T) {
+		actual, err := ApplyUnits(base, map[string]float64{"h": 1, "": 2})
+		ensureError(t, err, "duration missing units")
+		if strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("GOT: %q; WANT: no suggestion", err)
+		}
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		_, err := ApplyUnits(base, map[string]float64{"h": 1e9})
+		ensureError(t, err, "duration overflow")
+	})
 }
 
-func TestParseNowPlusDay(t *testing.T) {
-	before := time.Now().UTC().AddDate(0, 0, 1).Add(time.Hour).Add(time.Minute)
-	actual, err := ParseNow("", "now+1h1d1m")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestOffsetSeconds(t *testing.T) {
+	jan1 := time.Date(2009, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		value    string
+		expected float64
+	}{
+		{"1d", 86400},
+		{"1mo", 31 * 86400},
+		{"-1mo", -31 * 86400},
+		{"1.5h", 5400},
+		{"1y", 365 * 86400},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			actual, err := OffsetSeconds(jan1, tc.value)
+			ensureError(t, err)
+			if actual != tc.expected {
+				t.Errorf("Actual: %v; Expected: %v", actual, tc.expected)
+			}
+		})
 	}
-	after := time.Now().UTC().AddDate(0, 0, 1).Add(time.Hour).Add(time.Minute)
-	actual = actual.UTC()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+
+	t.Run("error", func(t *testing.T) {
+		_, err := OffsetSeconds(jan1, "1x")
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestAddDurationFullWidth(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("digits", func(t *testing.T) {
+		actual, err := AddDuration(base, "１ｈ")
+		ensureError(t, err)
+		if expected := base.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		actual, err := AddDuration(base, "－１.５h　30m")
+		ensureError(t, err)
+		if expected := base.Add(-90*time.Minute + 30*time.Minute); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("ascii unaffected", func(t *testing.T) {
+		actual, err := AddDuration(base, "1h30m")
+		ensureError(t, err)
+		if expected := base.Add(90 * time.Minute); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("micro sign unaffected", func(t *testing.T) {
+		actual, err := AddDuration(base, "5µs")
+		ensureError(t, err)
+		if expected := base.Add(5 * time.Microsecond); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := AddDuration(base, "１ｘ")
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestAddDurationMultipleFractions(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	actual, err := AddDuration(base, "+1.5days-3.21hours")
+	ensureError(t, err)
+
+	expected := base.Add(36 * time.Hour).Add(-3*time.Hour - 12*time.Minute - 36*time.Second)
+	if actual != expected {
+		t.Errorf("Actual: %s; Expected: %s", actual, expected)
 	}
 }
 
-func TestParseNowPlusAndMinus(t *testing.T) {
-	before := time.Now().UTC().Add(time.Hour).AddDate(0, 0, -1).Add(time.Minute)
-	actual, err := ParseNow("", "now+1h-1d+1m")
-	if err != nil {
-		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+func TestAddDurationUnitFirst(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("h2m30", func(t *testing.T) {
+		actual, err := AddDurationUnitFirst(base, "h2m30")
+		ensureError(t, err)
+		if expected := base.Add(2*time.Hour + 30*time.Minute); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("signed fractional", func(t *testing.T) {
+		actual, err := AddDurationUnitFirst(base, "-d1.5")
+		ensureError(t, err)
+		if expected := base.Add(-36 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("missing number", func(t *testing.T) {
+		_, err := AddDurationUnitFirst(base, "h2m")
+		ensureError(t, err, "missing number after unit")
+	})
+
+	t.Run("missing unit", func(t *testing.T) {
+		_, err := AddDurationUnitFirst(base, "2h")
+		ensureError(t, err, "duration missing units")
+	})
+
+	t.Run("unknown unit", func(t *testing.T) {
+		_, err := AddDurationUnitFirst(base, "xq2")
+		ensureError(t, err, "unknown unit")
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		actual, err := AddDurationUnitFirst(base, "m1 h1000000000")
+		ensureError(t, err, "duration overflow")
+		if e, ok := err.(*ParseError); !ok || e.Offset != 3 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 3)
+		}
+		if actual != base {
+			t.Errorf("Actual: %s; Expected: %s", actual, base)
+		}
+	})
+
+	t.Run("standard parser unchanged", func(t *testing.T) {
+		_, err := AddDuration(base, "h2m30")
+		ensureError(t, err, `missing unit after number "30"`)
+	})
+}
+
+func TestAddDurationSignBindsPerTerm(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"-1h2m":  -time.Hour + 2*time.Minute,
+		"-1h-2m": -time.Hour - 2*time.Minute,
+		"1h2m":   time.Hour + 2*time.Minute,
+		"-1h+2m": -time.Hour + 2*time.Minute,
+		// regression: an unsigned term following a negative term is added
+		"1h-2m3s":    time.Hour - 2*time.Minute + 3*time.Second,
+		"1h-2m 3s":   time.Hour - 2*time.Minute + 3*time.Second,
+		"-1d-2h3m4s": -26*time.Hour + 3*time.Minute + 4*time.Second,
 	}
-	after := time.Now().UTC().Add(time.Hour).AddDate(0, 0, -1).Add(time.Minute)
-	actual = actual.UTC()
-	if before.After(actual) || actual.After(after) {
-		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	for value, offset := range cases {
+		actual, err := AddDuration(base, value)
+		ensureError(t, err)
+		if expected := base.Add(offset); actual != expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+		}
+	}
+}
+
+func TestAddDurationWhitespace(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	actual, err := AddDuration(base, " 3 days\t4 hours ")
+	ensureError(t, err)
+
+	if expected := base.Add(76 * time.Hour); actual != expected {
+		t.Errorf("Actual: %s; Expected: %s", actual, expected)
+	}
+}
+
+func TestAddDurationAnd(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("connector", func(t *testing.T) {
+		actual, err := AddDuration(base, "1day and 2hours")
+		ensureError(t, err)
+		if expected := base.Add(26 * time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("unit", func(t *testing.T) {
+		_, err := AddDuration(base, "1andy")
+		ensureError(t, err, `unknown unit "andy"`)
+	})
+
+	t.Run("trailing", func(t *testing.T) {
+		_, err := AddDuration(base, "1day and")
+		ensureError(t, err, `unknown unit "and"`)
+	})
+}
+
+func TestAddDurationSignWords(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"plus 1 hour":                   time.Hour,
+		"minus 30 minutes":              -30 * time.Minute,
+		"plus 1 hour minus 30 minutes":  30 * time.Minute,
+		"minus 1 hour and 30 minutes":   -30 * time.Minute,
+		"minus 1 hour minus 30 minutes": -90 * time.Minute,
+		"minus\t2h":                     -2 * time.Hour,
+	}
+	for value, offset := range cases {
+		actual, err := AddDuration(base, value)
+		ensureError(t, err)
+		if expected := base.Add(offset); actual != expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+		}
+	}
+
+	t.Run("now", func(t *testing.T) {
+		before := time.Now().Add(-30 * time.Minute)
+		actual, err := ParseNow("", "now minus 30 minutes")
+		ensureError(t, err)
+		after := time.Now().Add(-30 * time.Minute)
+		if before.After(actual) || actual.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+		}
+	})
+
+	t.Run("without digits", func(t *testing.T) {
+		_, err := AddDuration(base, "1h minus ")
+		ensureError(t, err, "cannot parse sign without digits: 'minus'")
+	})
+
+	t.Run("not a unit", func(t *testing.T) {
+		_, err := AddDuration(base, "1plus")
+		ensureError(t, err, `unknown unit "plus"`)
+	})
+}
+
+func TestAddDurationWords(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	// Each unit must end at the whitespace before the next number, rather than absorbing it.
+	expected := base.AddDate(2, 3, 0).Add(4*24*time.Hour + 5*time.Hour)
+
+	for _, value := range []string{
+		"2 years 3 months 4 days 5 hours",
+		"2years 3months 4days 5hours",
+		"2y 3mo 4d 5h",
+		"2 years 3mo 4 days 5h",
+		"  2 years\t3 months  4 days 5 hours ",
+	} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := AddDuration(base, value)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+}
+
+func TestAddDurationAgo(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("1hour ago", func(t *testing.T) {
+		actual, err := AddDuration(base, "1hour ago")
+		ensureError(t, err)
+		if expected := base.Add(-time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("3 days ago", func(t *testing.T) {
+		actual, err := AddDuration(base, "3 days ago")
+		ensureError(t, err)
+		expected, err := AddDuration(base, "-3days")
+		ensureError(t, err)
+		if actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("-1h ago", func(t *testing.T) {
+		actual, err := AddDuration(base, "-1h ago")
+		ensureError(t, err)
+		if expected := base.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("requires separation", func(t *testing.T) {
+		_, err := AddDuration(base, "1hago")
+		ensureError(t, err, "unknown unit")
+	})
+
+	t.Run("bare ago", func(t *testing.T) {
+		for _, value := range []string{"ago", "  ago "} {
+			actual, err := AddDuration(base, value)
+			ensureError(t, err, `missing duration before "ago"`)
+			if e, ok := err.(*ParseError); !ok || e.Offset != strings.Index(value, "ago") {
+				t.Errorf("Value: %q; GOT: %#v; WANT: offset %d", value, err, strings.Index(value, "ago"))
+			}
+			if actual != base {
+				t.Errorf("Actual: %s; Expected: %s", actual, base)
+			}
+		}
+	})
+}
+
+// AddSeconds
+
+func TestAddSeconds(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("integer", func(t *testing.T) {
+		actual, err := AddSeconds(base, "90")
+		ensureError(t, err)
+		if expected := base.Add(90 * time.Second); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("fractional", func(t *testing.T) {
+		actual, err := AddSeconds(base, "-1.5")
+		ensureError(t, err)
+		if expected := base.Add(-1500 * time.Millisecond); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("rejects units", func(t *testing.T) {
+		_, err := AddSeconds(base, "1h")
+		ensureError(t, err, "cannot parse seconds")
+	})
+
+	t.Run("rejects exponent", func(t *testing.T) {
+		_, err := AddSeconds(base, "1e3")
+		ensureError(t, err, "cannot parse seconds")
+	})
+
+	t.Run("rejects sign without digits", func(t *testing.T) {
+		_, err := AddSeconds(base, "-.")
+		ensureError(t, err, "cannot parse seconds")
+	})
+
+	t.Run("rejects overflow", func(t *testing.T) {
+		_, err := AddSeconds(base, "99999999999999999999")
+		ensureError(t, err, "numeric overflow")
+	})
+}
+
+func TestParseRelativeSeconds(t *testing.T) {
+	for _, tc := range []struct {
+		value  string
+		offset time.Duration
+	}{
+		{"300", 300 * time.Second},
+		{"+300", 300 * time.Second},
+		{"-300", -300 * time.Second},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			before := time.Now().Add(tc.offset)
+			actual, err := ParseRelativeSeconds(tc.value)
+			ensureError(t, err)
+			after := time.Now().Add(tc.offset)
+			if before.After(actual) || actual.After(after) {
+				t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+			}
+		})
+	}
+
+	t.Run("rejects units", func(t *testing.T) {
+		_, err := ParseRelativeSeconds("300s")
+		ensureError(t, err, "cannot parse seconds")
+	})
+}
+
+func TestAddDurationSubsecondUnits(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Duration{
+		"1cs":            10 * time.Millisecond,
+		"3centiseconds":  30 * time.Millisecond,
+		"1ds":            100 * time.Millisecond,
+		"2.5deciseconds": 250 * time.Millisecond,
+		"500µs":          500 * time.Microsecond,
+		"250ns":          250 * time.Nanosecond,
+		"1d":             24 * time.Hour,
+		"1ds1d":          24*time.Hour + 100*time.Millisecond,
+	}
+	for value, offset := range cases {
+		actual, err := AddDuration(base, value)
+		ensureError(t, err)
+		if expected := base.Add(offset); actual != expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+		}
+	}
+}
+
+func TestAddDurationSuggestsUnit(t *testing.T) {
+	cases := map[string]string{
+		"now+1dya":      `unknown unit "dya"; did you mean "day"?`,
+		"now+1hour2mni": `unknown unit "mni"; did you mean "min"?`,
+		"now+3wekes":    `unknown unit "wekes"; did you mean "weeks"?`,
+		"now+1yaer":     `unknown unit "yaer"; did you mean "year"?`,
+	}
+	for value, want := range cases {
+		_, err := ParseNow("", value)
+		ensureError(t, err, want)
+	}
+
+	t.Run("nonsense", func(t *testing.T) {
+		_, err := ParseNow("", "now+1zzzz")
+		ensureError(t, err, `unknown unit "zzzz"`)
+		if err != nil && strings.Contains(err.Error(), "did you mean") {
+			t.Errorf("GOT: %v; WANT: no suggestion", err)
+		}
+	})
+}
+
+// ParseClockDuration
+
+func TestParseClockDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"01:30:00": 90 * time.Minute,
+		"90:00":    90 * time.Minute,
+		"1:2:3.5":  time.Hour + 2*time.Minute + 3500*time.Millisecond,
+		"0:59.25":  59250 * time.Millisecond,
+	}
+	for value, expected := range cases {
+		actual, err := ParseClockDuration(value)
+		ensureError(t, err)
+		if actual != expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+		}
+	}
+
+	for _, value := range []string{"", "90", "1:2:3:4", "1:x:3", "-1:30", "1.5:30", "1::3", "1:2.3.4", "1:."} {
+		_, err := ParseClockDuration(value)
+		ensureError(t, err, "cannot parse clock duration")
+	}
+}
+
+// Units
+
+func TestUnits(t *testing.T) {
+	units := Units()
+
+	if !sort.StringsAreSorted(units) {
+		t.Errorf("GOT: %v; WANT: sorted", units)
+	}
+
+	for _, want := range []string{"ns", "µs", "ms", "s", "m", "h", "d", "day", "w", "weeks", "mo", "month", "y", "years"} {
+		i := sort.SearchStrings(units, want)
+		if i == len(units) || units[i] != want {
+			t.Errorf("GOT: %v; WANT: %q", units, want)
+		}
+	}
+
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	for _, unit := range units {
+		if _, err := AddDuration(base, "1"+unit); err != nil {
+			t.Errorf("GOT: %v; WANT: %v; UNIT: %q", err, nil, unit)
+		}
+	}
+}
+
+// ParseWithMap
+
+func TestParseWithMapFloatingEpochPositive(t *testing.T) {
+	actual, err := ParseWithMap("", "1445535988.5", nil)
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+
+	nanos := int64(0.5 * float64(time.Second/time.Nanosecond))
+
+	expected := time.Unix(1445535988, nanos)
+	if actual != expected {
+		t.Errorf("Actual: %s; Expected: %s", actual, expected)
+	}
+}
+
+func TestParseWithMapFloatingEpochNegative(t *testing.T) {
+	_, err := ParseWithMap("", "-1445535988.5", nil)
+	if _, ok := err.(*time.ParseError); err == nil || !ok {
+		t.Errorf("Actual: %#v; Expected: %s", err, "negative floating point not allowed")
+	}
+}
+
+func TestParseWithMapEpochFastPath(t *testing.T) {
+	t.Run("plus sign", func(t *testing.T) {
+		actual, err := ParseWithMap("", "+1445535988", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1445535988, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("plus sign fractional", func(t *testing.T) {
+		actual, err := ParseWithMap("", "+1445535988.5", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1445535988, 500000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("plus sign leading zeros", func(t *testing.T) {
+		actual, err := ParseWithMap("", "+001445535988", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1445535988, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("plus sign with dict", func(t *testing.T) {
+		// A plus-prefixed epoch is an absolute time, never an offset from some base.
+		dict := map[string]time.Time{"start": time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)}
+		actual, err := ParseWithMap("", "+1445535988", dict)
+		ensureError(t, err)
+		if expected := time.Unix(1445535988, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		for _, value := range []string{"+Inf", "+inf", "+Infinity", "+NaN", "1e300", "@+Inf", "@1e300", "1e300+1h"} {
+			actual, err := ParseWithMap("", value, nil)
+			if err == nil {
+				t.Errorf("Value: %q; GOT: %s; WANT: error", value, actual)
+			}
+		}
+	})
+
+	t.Run("exponent", func(t *testing.T) {
+		actual, err := ParseWithMap("", "1.445535988e+09", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1445535988, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		actual, err := ParseWithMap(time.RFC3339, rfc3339, nil)
+		ensureError(t, err)
+		if expected := time.Unix(1136214245, 0); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("relative without dict", func(t *testing.T) {
+		_, err := ParseWithMap(time.ANSIC, "now-5s", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+
+	for _, value := range []string{"", "now", "-1", "2006-01-02", "15+3"} {
+		if mayBeEpoch(value) {
+			t.Errorf("GOT: %v; WANT: %v; VALUE: %q", true, false, value)
+		}
+	}
+}
+
+func TestParseWithMap(t *testing.T) {
+	before := time.Now().UTC()
+	dict := map[string]time.Time{
+		"start": time.Now().UTC().AddDate(0, 0, -7),
+	}
+	after := time.Now().UTC()
+
+	actual, err := ParseWithMap(time.ANSIC, "start+1week", dict)
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+// MustParse
+
+func TestMustParse(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		actual := MustParse(time.RFC3339, rfc3339)
+		expected := time.Unix(1136214245, 0)
+		if !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ensurePanic(t, "not-a-time", func() {
+			MustParse(time.RFC3339, "not-a-time")
+		})
+	})
+}
+
+func TestMustParseNow(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		before := time.Now().Add(time.Hour)
+		actual := MustParseNow("", "now+1h")
+		after := time.Now().Add(time.Hour)
+		if before.After(actual) || actual.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		ensurePanic(t, "now+1xq", func() {
+			MustParseNow("", "now+1xq")
+		})
+	})
+}
+
+func TestParseWithMapKeyPrecedence(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	dict := map[string]time.Time{
+		"s":            base,
+		"start":        base.AddDate(0, 0, 1),
+		"start_of_day": base.AddDate(0, 0, 2),
+	}
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"s", base},
+		{"s+1s", base.Add(time.Second)},
+		{"s-5s", base.Add(-5 * time.Second)},
+		{"start+1s", base.AddDate(0, 0, 1).Add(time.Second)},
+		{"start_of_day+1h", base.AddDate(0, 0, 2).Add(time.Hour)},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseWithMap("", c.value, dict)
+		ensureError(t, err)
+		if actual != c.expected {
+			t.Errorf("Value: %q; Actual: %s; Expected: %s", c.value, actual, c.expected)
+		}
+	}
+}
+
+// ParseNoEpoch
+
+func TestParseWithMapKeyDeterministic(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	// Equal length keys sharing a prefix, and keys nested within one another.
+	dict := map[string]time.Time{
+		"ab":   base,
+		"ac":   base.AddDate(0, 0, 1),
+		"a":    base.AddDate(0, 0, 2),
+		"abc":  base.AddDate(0, 0, 3),
+		"abcd": base.AddDate(0, 0, 4),
+	}
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"ab+1h", base.Add(time.Hour)},
+		{"ac+1h", base.AddDate(0, 0, 1).Add(time.Hour)},
+		{"a+1h", base.AddDate(0, 0, 2).Add(time.Hour)},
+		{"abc+1h", base.AddDate(0, 0, 3).Add(time.Hour)},
+		{"abcd+1h", base.AddDate(0, 0, 4).Add(time.Hour)},
+	}
+
+	// Map iteration order varies between loops, so repeat to detect any dependence on it.
+	for i := 0; i < 100; i++ {
+		for _, c := range cases {
+			actual, err := ParseWithMap("", c.value, dict)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Fatalf("Value: %q; Actual: %s; Expected: %s", c.value, actual, c.expected)
+			}
+		}
+	}
+}
+
+func TestParseWithMapEpochUnitSuffix(t *testing.T) {
+	expected := time.Unix(1609459200, 0)
+	for _, value := range []string{"1609459200s", "1609459200000ms", "1609459200000000us", "1609459200000000000ns"} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := ParseWithMap("", value, nil)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+
+	t.Run("fractional seconds", func(t *testing.T) {
+		actual, err := ParseWithMap("", "1609459200123ms", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200, 123000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		_, err := ParseWithMap("", "+1609459200s", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+
+	t.Run("unknown suffix", func(t *testing.T) {
+		_, err := ParseWithMap("", "1609459200m", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseWithMapEpochOffset(t *testing.T) {
+	t.Run("integer", func(t *testing.T) {
+		actual, err := ParseWithMap("", "1609459200+1h", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200+3600, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("fractional", func(t *testing.T) {
+		actual, err := ParseWithMap("", "1609459200.5-30m", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200-1800, 500000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("plus sign", func(t *testing.T) {
+		actual, err := ParseWithMap("", "+1609459200+1h", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200+3600, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("plus sign fractional", func(t *testing.T) {
+		actual, err := ParseWithMap("", "+1609459200.5-30m", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200-1800, 500000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("several terms", func(t *testing.T) {
+		actual, err := ParseWithMap("", "1609459200-1h+30m", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200-1800, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout wins", func(t *testing.T) {
+		actual, err := ParseWithMap("2006-01-02", "2020-12-25", nil)
+		ensureError(t, err)
+		if expected := time.Date(2020, time.December, 25, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		_, err := ParseWithMap("", "1609459200+1x", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+
+	t.Run("no epoch", func(t *testing.T) {
+		_, err := ParseNoEpoch("", "1609459200+1h", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseWithMapEpochDigitSeparators(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"1_609_459_200", time.Unix(1609459200, 0)},
+		{"1_609_459_200.5", time.Unix(1609459200, 500000000)},
+		{"1_609_459_200.2_5", time.Unix(1609459200, 250000000)},
+		{"+1_609_459_200", time.Unix(1609459200, 0)},
+		{"1_609_459_200_000ms", time.Unix(1609459200, 0)},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := ParseWithMap("", c.value, nil)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("not between digits", func(t *testing.T) {
+		for _, value := range []string{"_1609", "1609_", "1__609", "1609_.5", "1609._5"} {
+			_, err := ParseWithMap("", value, nil)
+			if _, ok := err.(*time.ParseError); err == nil || !ok {
+				t.Errorf("%s: Actual: %#v; Expected: %T", value, err, &time.ParseError{})
+			}
+		}
+	})
+
+	t.Run("no epoch", func(t *testing.T) {
+		_, err := ParseNoEpoch("", "1_609_459_200", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseWithMapEpochAtPrefix(t *testing.T) {
+	t.Run("integer", func(t *testing.T) {
+		actual, err := ParseWithMap("", "@1609459200", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("fractional", func(t *testing.T) {
+		actual, err := ParseWithMap("", "@1609459200.5", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200, 500000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("unit suffix", func(t *testing.T) {
+		actual, err := ParseWithMap("", "@1609459200123ms", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200, 123000000); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout would match", func(t *testing.T) {
+		actual, err := ParseWithMap("20060102", "@20201225", nil)
+		ensureError(t, err)
+		if expected := time.Unix(20201225, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("no epoch", func(t *testing.T) {
+		actual, err := ParseNoEpoch("", "@1609459200", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("dict wins", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		actual, err := ParseWithMap("", "@start+1h", map[string]time.Time{"@start": start})
+		ensureError(t, err)
+		if expected := start.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, value := range []string{"@", "@abc", "@-1609459200", "@1609459200+1h", "@1609459200 "} {
+			_, err := ParseWithMap("", value, nil)
+			ensureError(t, err, "expected a non-negative epoch value after '@'")
+		}
+	})
+}
+
+func TestParseWithMapLayoutOffset(t *testing.T) {
+	cases := []struct {
+		layout, value string
+		expected      time.Time
+	}{
+		{"2006-01-02", "2021-01-01+3mo", time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)},
+		{"2006-01-02", "2021-01-01-1d", time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{"2006-01-02", "2021-01-01+1d12h", time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC)},
+		{"2006-01-02", "2021-01-01", time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"20060102", "20210101-3d", time.Date(2020, time.December, 29, 0, 0, 0, 0, time.UTC)},
+		{time.RFC3339, "2021-01-01T00:00:00Z+1h", time.Date(2021, time.January, 1, 1, 0, 0, 0, time.UTC)},
+		{time.RFC3339, "2021-01-01T00:00:00-05:00+1h", time.Date(2021, time.January, 1, 6, 0, 0, 0, time.UTC)},
+		{time.RFC3339, "2021-01-01T00:00:00+05:00-1h", time.Date(2020, time.December, 31, 18, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := ParseWithMap(c.layout, c.value, nil)
+			ensureError(t, err)
+			if !actual.Equal(c.expected) {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("in location", func(t *testing.T) {
+		loc := time.FixedZone("EST", -5*3600)
+		actual, err := ParseWithMapInLocation("2006-01-02", "2021-01-01+3mo", nil, loc)
+		ensureError(t, err)
+		if expected := time.Date(2021, time.April, 1, 0, 0, 0, 0, loc); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout first", func(t *testing.T) {
+		p := Parser{Layout: "20060102", LayoutFirst: true}
+		for value, expected := range map[string]time.Time{
+			"20210101":    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+			"20210101-3d": time.Date(2020, time.December, 29, 0, 0, 0, 0, time.UTC),
+		} {
+			actual, err := p.Parse(value)
+			ensureError(t, err)
+			if !actual.Equal(expected) {
+				t.Errorf("Value: %q; Actual: %s; Expected: %s", value, actual, expected)
+			}
+		}
+	})
+
+	t.Run("epoch without layout", func(t *testing.T) {
+		actual, err := ParseWithMap("", "20210101-3d", nil)
+		ensureError(t, err)
+		if expected := time.Unix(20210101-3*86400, 0); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("explain", func(t *testing.T) {
+		_, x, err := ParseExplain("2006-01-02", "2021-01-01+3mo", nil)
+		ensureError(t, err)
+		if x.Anchor != "layout" || x.Offset != "+3mo" {
+			t.Errorf("Actual: %#v; Expected: layout anchor with offset %q", x, "+3mo")
+		}
+	})
+
+	t.Run("not after sign", func(t *testing.T) {
+		for _, value := range []string{"2021-01-01 3mo", "2021-01-01+3x", "2021-01-01+", "2021-01+3mo"} {
+			_, err := ParseWithMap("2006-01-02", value, nil)
+			if _, ok := err.(*time.ParseError); err == nil || !ok {
+				t.Errorf("%s: Actual: %#v; Expected: %T", value, err, &time.ParseError{})
+			}
+		}
+	})
+}
+
+func TestParseWithMapEpochKeyword(t *testing.T) {
+	t.Run("epoch", func(t *testing.T) {
+		actual, err := ParseWithMap(time.RFC3339, "epoch", nil)
+		ensureError(t, err)
+		if expected := time.Unix(0, 0).UTC(); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		actual, err := ParseNow(time.RFC3339, "epoch+365d")
+		ensureError(t, err)
+		if expected := time.Date(1971, time.January, 1, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := ParseWithMap(time.RFC3339, "epoch+1x", nil)
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 7 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 7)
+		}
+	})
+
+	t.Run("dict wins", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		actual, err := ParseWithMap(time.RFC3339, "epoch+1h", map[string]time.Time{"epoch": start})
+		ensureError(t, err)
+		if expected := start.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestParseNoEpoch(t *testing.T) {
+	t.Run("epoch", func(t *testing.T) {
+		_, err := ParseNoEpoch(time.RFC3339, "1445535988", nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+
+	t.Run("dict", func(t *testing.T) {
+		now := time.Now()
+		actual, err := ParseNoEpoch(time.RFC3339, "now+1h", map[string]time.Time{"now": now})
+		ensureError(t, err)
+		if expected := now.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		actual, err := ParseNoEpoch(time.RFC3339, rfc3339, nil)
+		ensureError(t, err)
+		if expected := time.Unix(1136214245, 0); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+// ParseClamped
+
+func TestParseClamped(t *testing.T) {
+	base := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	dict := map[string]time.Time{"base": base}
+	min, max := base.Add(-time.Hour), base.Add(time.Hour)
+
+	cases := []struct {
+		value    string
+		expected time.Time
+		clamped  bool
+	}{
+		{"base-2h", min, true},
+		{"base+30m", base.Add(30 * time.Minute), false},
+		{"base+1h", max, false},
+		{"base+2h", max, true},
+	}
+
+	for _, c := range cases {
+		actual, clamped, err := ParseClamped("", c.value, dict, min, max)
+		ensureError(t, err)
+		if actual != c.expected || clamped != c.clamped {
+			t.Errorf("Value: %q; Actual: %s, %t; Expected: %s, %t", c.value, actual, clamped, c.expected, c.clamped)
+		}
+	}
+
+	t.Run("min after max", func(t *testing.T) {
+		_, _, err := ParseClamped("", "base", dict, max, min)
+		ensureError(t, err, "is after maximum")
+	})
+}
+
+// ParseInZoneName
+
+func TestParseInZoneName(t *testing.T) {
+	const layout = "2006-01-02 15:04:05"
+
+	t.Run("with zone name", func(t *testing.T) {
+		actual, err := ParseInZoneName(layout, "2006-01-02 15:04:05 America/New_York")
+		ensureError(t, err)
+		if got, want := actual.Location().String(), "America/New_York"; got != want {
+			t.Errorf("GOT: %v; WANT: %v", got, want)
+		}
+		if expected := time.Date(2006, time.January, 2, 20, 4, 5, 0, time.UTC); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("without zone name", func(t *testing.T) {
+		actual, err := ParseInZoneName(layout, "2006-01-02 15:04:05")
+		ensureError(t, err)
+		if expected := time.Date(2006, time.January, 2, 15, 4, 5, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("bogus zone name", func(t *testing.T) {
+		_, err := ParseInZoneName(layout, "2006-01-02 15:04:05 Mars/Olympus_Mons")
+		ensureError(t, err, "Mars/Olympus_Mons")
+	})
+}
+
+// ParseNow
+
+func TestParseBasicISO8601(t *testing.T) {
+	t.Run("utc", func(t *testing.T) {
+		actual, err := ParseBasicISO8601("20091110T230000Z")
+		ensureError(t, err)
+		if expected := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		actual, err := ParseBasicISO8601("20091110T180000-0500")
+		ensureError(t, err)
+		if expected := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		if _, offset := actual.Zone(); offset != -5*3600 {
+			t.Errorf("Actual: %d; Expected: %d", offset, -5*3600)
+		}
+	})
+
+	t.Run("no zone", func(t *testing.T) {
+		actual, err := ParseBasicISO8601("20091110T230000")
+		ensureError(t, err)
+		if expected := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"2009-11-10T23:00:00Z",
+			"20091110 230000Z",
+			"2009111xT230000Z",
+			"20091110T230000+05",
+			"20091110T230000EST",
+		} {
+			_, err := ParseBasicISO8601(value)
+			ensureError(t, err, "cannot parse basic ISO 8601 time")
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := ParseBasicISO8601("20091310T230000Z")
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseISOWeekDate(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"2021-W05-3", time.Date(2021, time.February, 3, 0, 0, 0, 0, time.UTC)},
+		{"2009-W46-2", time.Date(2009, time.November, 10, 0, 0, 0, 0, time.UTC)},
+		{"2021-W01-1", time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)},
+		{"2020-W01-1", time.Date(2019, time.December, 30, 0, 0, 0, 0, time.UTC)},
+		{"2020-W53-7", time.Date(2021, time.January, 3, 0, 0, 0, 0, time.UTC)},
+		{"2015-W53-5", time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"2021-W52-7", time.Date(2022, time.January, 2, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := ParseISOWeekDate(c.value)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("malformed", func(t *testing.T) {
+		cases := []struct {
+			value, expected string
+		}{
+			{"", "expected YYYY-Www-D"},
+			{"2021-05-3", "expected YYYY-Www-D"},
+			{"2021W053", "expected YYYY-Www-D"},
+			{"2021-W5-3", "expected YYYY-Www-D"},
+			{"2021-W0x-3", "non-digit"},
+			{"2021-W00-3", "week out of range"},
+			{"2021-W54-3", "week out of range"},
+			{"2021-W05-0", "day out of range"},
+			{"2021-W05-8", "day out of range"},
+			{"2021-W53-1", "year 2021 has no week 53"},
+		}
+		for _, c := range cases {
+			_, err := ParseISOWeekDate(c.value)
+			ensureError(t, err, "cannot parse ISO week date", c.expected)
+		}
+	})
+}
+
+func TestParseHTTPDate(t *testing.T) {
+	expected := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		value  string
+		offset int
+	}{
+		{"Tue, 10 Nov 2009 23:00:00 GMT", 0},
+		{"Tue, 10 Nov 2009 23:00:00 UTC", 0},
+		{"Tue, 10 Nov 2009 18:00:00 EST", -5 * 3600},
+		{"Tue, 10 Nov 2009 15:00:00 PST", -8 * 3600},
+		{"Tue, 10 Nov 2009 19:00:00 EDT", -4 * 3600},
+		{"Tue, 10 Nov 2009 13:00:00 HST", -10 * 3600},
+		{"Tue, 10 Nov 2009 19:30:00 NST", -(3*3600 + 1800)},
+		{"Tue, 10 Nov 2009 18:00:00 -0500", -5 * 3600},
+		{"10 Nov 09 23:00 GMT", 0},
+		{"10 Nov 09 17:00 CST", -6 * 3600},
+		{"10 Nov 09 16:00 -0700", -7 * 3600},
+	}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := ParseHTTPDate(c.value)
+			ensureError(t, err)
+			if !actual.Equal(expected) {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+			if _, offset := actual.Zone(); offset != c.offset {
+				t.Errorf("Offset: Actual: %d; Expected: %d", offset, c.offset)
+			}
+		})
+	}
+
+	t.Run("unknown zone", func(t *testing.T) {
+		_, err := ParseHTTPDate("Tue, 10 Nov 2009 23:00:00 CET")
+		ensureError(t, err, `unknown zone abbreviation "CET"`)
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, value := range []string{"", "2009-11-10T23:00:00Z", "Tue, 10 Nov 2009 23:00 GMT"} {
+			_, err := ParseHTTPDate(value)
+			ensureError(t, err, "cannot parse HTTP date")
+		}
+	})
+}
+
+func TestParseFlexibleDate(t *testing.T) {
+	expected := time.Date(2009, time.November, 10, 0, 0, 0, 0, time.UTC)
+
+	for _, value := range []string{
+		"10 Nov 2009",
+		"10 November 2009",
+		"10-Nov-2009",
+		"Nov 10 2009",
+		"Nov 10, 2009",
+		"November 10, 2009",
+		"Tue 10 Nov 2009",
+		"Tue, 10 Nov 2009",
+		"Tuesday, 10 November 2009",
+		"Tue Nov 10 2009",
+		"Tuesday, November 10, 2009",
+	} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := ParseFlexibleDate(value)
+			ensureError(t, err)
+			if actual != expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, expected)
+			}
+		})
+	}
+
+	t.Run("single digit day", func(t *testing.T) {
+		actual, err := ParseFlexibleDate("2 January 2006")
+		ensureError(t, err)
+		if expected := time.Date(2006, time.January, 2, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		for _, value := range []string{
+			"",
+			"2009-11-10",
+			"10 Novembre 2009",
+			"32 Nov 2009",
+			"10 Nov",
+		} {
+			_, err := ParseFlexibleDate(value)
+			ensureError(t, err, "cannot parse date")
+		}
+	})
+}
+
+func TestParseNow(t *testing.T) {
+	before := time.Now()
+	actual, err := ParseNow("", "now")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowMinusSecond(t *testing.T) {
+	before := time.Now().UTC().Add(-2 * time.Second)
+	actual, err := ParseNow("", "now-2second")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().Add(-2 * time.Second)
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowMinusMillisecond(t *testing.T) {
+	before := time.Now()
+	time.Sleep(10 * time.Millisecond)
+	actual, err := ParseNow("", "now-10ms")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowPlusMillisecond(t *testing.T) {
+	before := time.Now()
+	actual, err := ParseNow("", "now+10ms")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	time.Sleep(10 * time.Millisecond)
+	after := time.Now()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowPlusQuarterDay(t *testing.T) {
+	before := time.Now().UTC().Add(6 * time.Hour)
+	actual, err := ParseNow("", "now+0.25day")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().Add(6 * time.Hour)
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowPlusDay(t *testing.T) {
+	before := time.Now().UTC().AddDate(0, 0, 1).Add(time.Hour).Add(time.Minute)
+	actual, err := ParseNow("", "now+1h1d1m")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().AddDate(0, 0, 1).Add(time.Hour).Add(time.Minute)
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowPlusAndMinus(t *testing.T) {
+	before := time.Now().UTC().Add(time.Hour).AddDate(0, 0, -1).Add(time.Minute)
+	actual, err := ParseNow("", "now+1h-1d+1m")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().Add(time.Hour).AddDate(0, 0, -1).Add(time.Minute)
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
 	}
 }
 
@@ -317,13 +1974,840 @@ func TestParseNowMinusAndPlus(t *testing.T) {
 	if err != nil {
 		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
 	}
-	after := time.Now().UTC().Add(-time.Hour*12).AddDate(0, 0, 34).Add(-time.Minute * 56)
+	after := time.Now().UTC().Add(-time.Hour*12).AddDate(0, 0, 34).Add(-time.Minute * 56)
+	actual = actual.UTC()
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowAgo(t *testing.T) {
+	before := time.Now().UTC().AddDate(0, 0, -3)
+	actual, err := ParseNow("", "now 3 days ago")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().AddDate(0, 0, -3)
 	actual = actual.UTC()
 	if before.After(actual) || actual.After(after) {
 		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
 	}
 }
 
+func TestParseNowBareAgo(t *testing.T) {
+	_, err := ParseNow("", "now ago")
+	ensureError(t, err, `missing duration before "ago"`)
+	if e, ok := err.(*ParseError); !ok || e.Offset != 4 {
+		t.Errorf("GOT: %#v; WANT: offset %d", err, 4)
+	}
+}
+
+func TestParseNowRelativeWeekday(t *testing.T) {
+	// Wednesday
+	now := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+	midnight := time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		name := strings.ToLower(weekday.String())
+
+		t.Run("next "+name, func(t *testing.T) {
+			actual, err := parseNow(now, "", "next "+name, nil, nil, nil)
+			ensureError(t, err)
+			if actual.Weekday() != weekday {
+				t.Errorf("Actual: %s; Expected: %s", actual.Weekday(), weekday)
+			}
+			if days := actual.Sub(midnight) / (24 * time.Hour); days < 1 || days > 7 || actual.Sub(midnight)%(24*time.Hour) != 0 {
+				t.Errorf("Actual: %s; Expected midnight within the following seven days", actual)
+			}
+		})
+
+		t.Run("last "+name, func(t *testing.T) {
+			actual, err := parseNow(now, "", "last "+name, nil, nil, nil)
+			ensureError(t, err)
+			if actual.Weekday() != weekday {
+				t.Errorf("Actual: %s; Expected: %s", actual.Weekday(), weekday)
+			}
+			if days := midnight.Sub(actual) / (24 * time.Hour); days < 1 || days > 7 || midnight.Sub(actual)%(24*time.Hour) != 0 {
+				t.Errorf("Actual: %s; Expected midnight within the preceding seven days", actual)
+			}
+		})
+	}
+
+	t.Run("same weekday", func(t *testing.T) {
+		actual, err := parseNow(now, "", "next wednesday", nil, nil, nil)
+		ensureError(t, err)
+		if expected := midnight.AddDate(0, 0, 7); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		actual, err = parseNow(now, "", "last Wed", nil, nil, nil)
+		ensureError(t, err)
+		if expected := midnight.AddDate(0, 0, -7); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		actual, err := parseNow(now, "", "next monday+9h", nil, nil, nil)
+		ensureError(t, err)
+		if expected := time.Date(2020, time.November, 23, 9, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := parseNow(now, "", "next monday+9x", nil, nil, nil)
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 13 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 13)
+		}
+	})
+}
+
+func TestParseNowDetailed(t *testing.T) {
+	t.Run("now", func(t *testing.T) {
+		before := time.Now()
+		base, result, err := ParseNowDetailed("", "now-90m")
+		ensureError(t, err)
+		after := time.Now()
+		if before.After(base) || base.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", base, before, after)
+		}
+		if got, want := result.Sub(base), -90*time.Minute; got != want {
+			t.Errorf("GOT: %s; WANT: %s", got, want)
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		_, result, err := ParseNowDetailed(time.RFC3339, rfc3339)
+		ensureError(t, err)
+		if expected := time.Unix(1136214245, 0); !result.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", result, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, _, err := ParseNowDetailed("", "now-1x")
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestParseNowFormat(t *testing.T) {
+	t.Run("date", func(t *testing.T) {
+		before := time.Now().Format("2006-01-02")
+		actual, err := ParseNowFormat("", "now", "2006-01-02")
+		ensureError(t, err)
+		after := time.Now().Format("2006-01-02")
+		if actual != before && actual != after {
+			t.Errorf("Actual: %s; Expected: %s", actual, after)
+		}
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		actual, err := ParseNowFormat(time.RFC3339, "2009-11-10T23:00:00Z", "Jan 2, 2006 at 15:04")
+		ensureError(t, err)
+		if expected := "Nov 10, 2009 at 23:00"; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("default layout", func(t *testing.T) {
+		actual, err := ParseNowFormat("", "1257894000", "")
+		ensureError(t, err)
+		if expected := time.Unix(1257894000, 0).Format(time.RFC3339); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		actual, err := ParseNowFormat("", "now+1x", "")
+		ensureError(t, err, `unknown unit "x"`)
+		if actual != "" {
+			t.Errorf("Actual: %q; Expected: %q", actual, "")
+		}
+	})
+}
+
+func TestParseList(t *testing.T) {
+	t.Run("now", func(t *testing.T) {
+		actual, err := ParseList("", "now, now+1h,now+2h ", nil)
+		ensureError(t, err)
+		if len(actual) != 3 {
+			t.Fatalf("GOT: %v; WANT: %d times", actual, 3)
+		}
+		for i := 1; i < len(actual); i++ {
+			if got, want := actual[i].Sub(actual[i-1]), time.Hour; got != want {
+				t.Errorf("Index: %d; GOT: %s; WANT: %s", i, got, want)
+			}
+		}
+	})
+
+	t.Run("mixed", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		actual, err := ParseList(time.RFC3339, "start, 1257894000, 2009-11-10T23:00:00Z", map[string]time.Time{"start": start})
+		ensureError(t, err)
+		for i, tm := range actual {
+			if !tm.Equal(start) {
+				t.Errorf("Index: %d; Actual: %s; Expected: %s", i, tm, start)
+			}
+		}
+	})
+
+	t.Run("bad element", func(t *testing.T) {
+		actual, err := ParseList("", "now, now+1x, now+2h", nil)
+		ensureError(t, err, "cannot parse list element 1", `unknown unit "x"`)
+		if actual != nil {
+			t.Errorf("Actual: %v; Expected: %v", actual, nil)
+		}
+		var e *ParseError
+		if !errors.As(err, &e) || e.Input != "now+1x" {
+			t.Errorf("GOT: %#v; WANT: %T for %q", err, e, "now+1x")
+		}
+	})
+
+	t.Run("empty element", func(t *testing.T) {
+		_, err := ParseList("", "now,,now", nil)
+		ensureError(t, err, "cannot parse list element 1", "empty value")
+	})
+
+	t.Run("trailing comma", func(t *testing.T) {
+		_, err := ParseList("", "now, ", nil)
+		ensureError(t, err, "cannot parse list element 1", "empty value")
+	})
+}
+
+func TestParseRelativeTo(t *testing.T) {
+	const ref = "2020-11-18T15:04:05Z"
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"now", time.Date(2020, time.November, 18, 15, 4, 5, 0, time.UTC)},
+		{"now+1h", time.Date(2020, time.November, 18, 16, 4, 5, 0, time.UTC)},
+		{"now-1mo", time.Date(2020, time.October, 18, 15, 4, 5, 0, time.UTC)},
+		{"tomorrow 9am", time.Date(2020, time.November, 19, 9, 0, 0, 0, time.UTC)},
+		{"bom", time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"start+1h", time.Date(2009, time.November, 11, 0, 0, 0, 0, time.UTC)},
+		{"2009-11-10T23:00:00Z", time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+	}
+	dict := map[string]time.Time{"start": time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)}
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := ParseRelativeTo(time.RFC3339, ref, time.RFC3339, c.value, dict)
+			ensureError(t, err)
+			if !actual.Equal(c.expected) {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("epoch reference", func(t *testing.T) {
+		actual, err := ParseRelativeTo("", "1609459200", "", "now+1h", nil)
+		ensureError(t, err)
+		if expected := time.Unix(1609459200+3600, 0); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("bad reference", func(t *testing.T) {
+		_, err := ParseRelativeTo(time.RFC3339, "now", "", "now+1h", nil)
+		ensureError(t, err, "cannot parse reference time")
+		var e *time.ParseError
+		if !errors.As(err, &e) {
+			t.Errorf("GOT: %#v; WANT: %T", err, e)
+		}
+	})
+
+	t.Run("bad value", func(t *testing.T) {
+		_, err := ParseRelativeTo(time.RFC3339, ref, "", "now+1x", nil)
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestParseChain(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	t.Run("now", func(t *testing.T) {
+		actual, err := ParseChain("", []string{"now", "prev+1h", "prev+1h"}, nil)
+		ensureError(t, err)
+		if len(actual) != 3 {
+			t.Fatalf("GOT: %v; WANT: %d times", actual, 3)
+		}
+		for i := 1; i < len(actual); i++ {
+			if got, want := actual[i].Sub(actual[i-1]), time.Hour; got != want {
+				t.Errorf("Index: %d; GOT: %s; WANT: %s", i, got, want)
+			}
+		}
+	})
+
+	t.Run("dict", func(t *testing.T) {
+		dict := map[string]time.Time{"start": start, "prev": time.Unix(0, 0)}
+		actual, err := ParseChain(time.RFC3339, []string{"start", "prev+1d", "prev-30m", "start+1h"}, dict)
+		ensureError(t, err)
+		expected := []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 1).Add(-30 * time.Minute), start.Add(time.Hour)}
+		if len(actual) != len(expected) {
+			t.Fatalf("GOT: %v; WANT: %v", actual, expected)
+		}
+		for i := range expected {
+			if !actual[i].Equal(expected[i]) {
+				t.Errorf("Index: %d; Actual: %s; Expected: %s", i, actual[i], expected[i])
+			}
+		}
+	})
+
+	t.Run("first uses prev", func(t *testing.T) {
+		actual, err := ParseChain("", []string{"prev+1h", "prev+1h"}, nil)
+		ensureError(t, err, "cannot parse chain element 0", `"prev" has no previous element`)
+		if actual != nil {
+			t.Errorf("Actual: %v; Expected: %v", actual, nil)
+		}
+	})
+
+	t.Run("first uses longer key", func(t *testing.T) {
+		actual, err := ParseChain("", []string{"previous+1h", "prev+1h"}, map[string]time.Time{"previous": start})
+		ensureError(t, err)
+		if expected := start.Add(2 * time.Hour); len(actual) != 2 || !actual[1].Equal(expected) {
+			t.Errorf("Actual: %v; Expected: %s last", actual, expected)
+		}
+	})
+
+	t.Run("bad element", func(t *testing.T) {
+		_, err := ParseChain("", []string{"now", "prev+1x"}, nil)
+		ensureError(t, err, "cannot parse chain element 1", `unknown unit "x"`)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		actual, err := ParseChain("", nil, nil)
+		ensureError(t, err)
+		if len(actual) != 0 {
+			t.Errorf("Actual: %v; Expected: no times", actual)
+		}
+	})
+}
+
+func TestParseSeries(t *testing.T) {
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	dict := map[string]time.Time{"start": start}
+
+	t.Run("daily", func(t *testing.T) {
+		actual, err := ParseSeries("", "now-7d:now:1d", nil)
+		ensureError(t, err)
+		if len(actual) != 8 {
+			t.Fatalf("GOT: %d times; WANT: %d", len(actual), 8)
+		}
+		for i := 1; i < len(actual); i++ {
+			if got, want := actual[i].Sub(actual[i-1]), 24*time.Hour; got != want {
+				t.Errorf("Index: %d; GOT: %s; WANT: %s", i, got, want)
+			}
+		}
+	})
+
+	t.Run("end not on a step", func(t *testing.T) {
+		actual, err := ParseSeries("", "start:start+150m:1h", dict)
+		ensureError(t, err)
+		expected := []time.Time{start, start.Add(time.Hour), start.Add(2 * time.Hour)}
+		if len(actual) != len(expected) {
+			t.Fatalf("GOT: %v; WANT: %v", actual, expected)
+		}
+		for i := range expected {
+			if actual[i] != expected[i] {
+				t.Errorf("Index: %d; Actual: %s; Expected: %s", i, actual[i], expected[i])
+			}
+		}
+	})
+
+	t.Run("rfc3339", func(t *testing.T) {
+		actual, err := ParseSeries(time.RFC3339, "2009-11-10T23:00:00Z:2009-11-11T01:00:00Z:30m", nil)
+		ensureError(t, err)
+		if len(actual) != 5 || actual[0] != start || actual[4] != start.Add(2*time.Hour) {
+			t.Errorf("GOT: %v; WANT: 5 times from %s", actual, start)
+		}
+	})
+
+	t.Run("monthly", func(t *testing.T) {
+		actual, err := ParseSeries("", "start:start+3mo:1mo", dict)
+		ensureError(t, err)
+		if len(actual) != 4 || actual[3] != start.AddDate(0, 3, 0) {
+			t.Errorf("GOT: %v; WANT: 4 times through %s", actual, start.AddDate(0, 3, 0))
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		actual, err := ParseSeries("", "start:start:1h", dict)
+		ensureError(t, err)
+		if len(actual) != 1 || actual[0] != start {
+			t.Errorf("GOT: %v; WANT: %v", actual, []time.Time{start})
+		}
+	})
+
+	t.Run("zero step", func(t *testing.T) {
+		for _, value := range []string{"now-7d:now:0s", "now-7d:now:", "now-7d:now:-1d"} {
+			_, err := ParseSeries("", value, nil)
+			ensureError(t, err, "step must be positive")
+		}
+	})
+
+	t.Run("end before start", func(t *testing.T) {
+		_, err := ParseSeries("", "now:now-7d:1d", nil)
+		ensureError(t, err, "is before start")
+	})
+
+	t.Run("too long", func(t *testing.T) {
+		_, err := ParseSeries("", "now-1y:now:1s", nil)
+		ensureError(t, err, "more than 1000000 times")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		_, err := ParseSeries("", "now", nil)
+		ensureError(t, err, "expected start:end:step")
+		_, err = ParseSeries("", "now:1d", nil)
+		ensureError(t, err, "expected start:end:step")
+		_, err = ParseSeries("", "now-7x:now:1d", nil)
+		ensureError(t, err, "cannot parse series", `unknown unit "x"`)
+		_, err = ParseSeries("", "now-7d:now:1x", nil)
+		ensureError(t, err, "cannot parse series step", `unknown unit "x"`)
+	})
+}
+
+func TestInPastInFuture(t *testing.T) {
+	cases := []struct {
+		value        string
+		past, future bool
+	}{
+		{"now-1h", true, false},
+		{"now+1h", false, true},
+		{"now-1ns", true, false},
+		{"now", false, false},
+		{"epoch", true, false},
+		{"2999-01-01T00:00:00Z", false, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			past, _, err := InPast(time.RFC3339, c.value, nil)
+			ensureError(t, err)
+			if past != c.past {
+				t.Errorf("InPast: Actual: %v; Expected: %v", past, c.past)
+			}
+			future, _, err := InFuture(time.RFC3339, c.value, nil)
+			ensureError(t, err)
+			if future != c.future {
+				t.Errorf("InFuture: Actual: %v; Expected: %v", future, c.future)
+			}
+		})
+	}
+
+	t.Run("dict", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		past, actual, err := InPast(time.RFC3339, "start+1h", map[string]time.Time{"start": start})
+		ensureError(t, err)
+		if !past {
+			t.Errorf("Actual: %v; Expected: %v", past, true)
+		}
+		if expected := start.Add(time.Hour); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, _, err := InFuture(time.RFC3339, "now+1x", nil)
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestParseNowBounded(t *testing.T) {
+	const maxOffset = 90 * 24 * time.Hour
+
+	for _, value := range []string{"now", "now+1d", "now-1d", "now-90d"} {
+		t.Run(value, func(t *testing.T) {
+			before := time.Now()
+			actual, err := ParseNowBounded(time.RFC3339, value, maxOffset)
+			ensureError(t, err)
+			if actual.Before(before.Add(-maxOffset)) || actual.After(time.Now().Add(maxOffset)) {
+				t.Errorf("Actual: %s; Expected within %s of now", actual, maxOffset)
+			}
+		})
+	}
+
+	for _, value := range []string{"now+1y", "now-4mo", "now+91d", "2999-01-01T00:00:00Z", "epoch"} {
+		t.Run(value, func(t *testing.T) {
+			actual, err := ParseNowBounded(time.RFC3339, value, maxOffset)
+			ensureError(t, err, "exceeds maximum of 2160h0m0s")
+			if !actual.IsZero() {
+				t.Errorf("Actual: %s; Expected: zero time", actual)
+			}
+		})
+	}
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := ParseNowBounded(time.RFC3339, "now+1x", maxOffset)
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
+func TestParseTolerance(t *testing.T) {
+	t.Run("now", func(t *testing.T) {
+		before := time.Now()
+		start, end, err := ParseTolerance("", "now±5m")
+		ensureError(t, err)
+		after := time.Now()
+		if got, want := end.Sub(start), 10*time.Minute; got != want {
+			t.Errorf("GOT: %s; WANT: %s", got, want)
+		}
+		center := start.Add(5 * time.Minute)
+		if before.After(center) || center.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", center, before, after)
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		start, end, err := ParseTolerance(time.RFC3339, "2009-11-10T23:00:00Z ± 1mo")
+		ensureError(t, err)
+		if expected := time.Date(2009, time.October, 10, 23, 0, 0, 0, time.UTC); start != expected {
+			t.Errorf("Actual: %s; Expected: %s", start, expected)
+		}
+		if expected := time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC); end != expected {
+			t.Errorf("Actual: %s; Expected: %s", end, expected)
+		}
+	})
+
+	t.Run("missing operator", func(t *testing.T) {
+		_, _, err := ParseTolerance("", "now+5m")
+		ensureError(t, err, "missing '±'")
+	})
+
+	t.Run("multiple operators", func(t *testing.T) {
+		_, _, err := ParseTolerance("", "now±5m±1m")
+		ensureError(t, err, "multiple '±'")
+	})
+
+	t.Run("missing duration", func(t *testing.T) {
+		_, _, err := ParseTolerance("", "now±")
+		ensureError(t, err, "missing duration")
+	})
+
+	t.Run("negative duration", func(t *testing.T) {
+		for _, value := range []string{"now±-5m", "now ± 1h-2h"} {
+			start, end, err := ParseTolerance("", value)
+			ensureError(t, err, "negative duration")
+			if !start.IsZero() || !end.IsZero() {
+				t.Errorf("Value: %q; GOT: %s, %s; WANT: zero times", value, start, end)
+			}
+		}
+	})
+
+	t.Run("zero duration", func(t *testing.T) {
+		start, end, err := ParseTolerance(time.RFC3339, "2009-11-10T23:00:00Z±0s")
+		ensureError(t, err)
+		if start != end {
+			t.Errorf("Actual: %s; Expected: %s", start, end)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, _, err := ParseTolerance("", "now±5x")
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 6 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 6)
+		}
+	})
+}
+
+func TestParseNowRelativeDay(t *testing.T) {
+	now := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"today", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)},
+		{"tomorrow", time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC)},
+		{"yesterday", time.Date(2020, time.November, 17, 0, 0, 0, 0, time.UTC)},
+		{"today T15:30", time.Date(2020, time.November, 18, 15, 30, 0, 0, time.UTC)},
+		{"tomorrow T09:00:00", time.Date(2020, time.November, 19, 9, 0, 0, 0, time.UTC)},
+		{"today T15:30+1h", time.Date(2020, time.November, 18, 16, 30, 0, 0, time.UTC)},
+		{"today-1h", time.Date(2020, time.November, 17, 23, 0, 0, 0, time.UTC)},
+		{"next monday T09:00", time.Date(2020, time.November, 23, 9, 0, 0, 0, time.UTC)},
+		{"today 3:30pm", time.Date(2020, time.November, 18, 15, 30, 0, 0, time.UTC)},
+		{"today 12:00am", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)},
+		{"today 12:00pm", time.Date(2020, time.November, 18, 12, 0, 0, 0, time.UTC)},
+		{"today 12:30am", time.Date(2020, time.November, 18, 0, 30, 0, 0, time.UTC)},
+		{"tomorrow 9am", time.Date(2020, time.November, 19, 9, 0, 0, 0, time.UTC)},
+		{"yesterday 11:59:59PM", time.Date(2020, time.November, 17, 23, 59, 59, 0, time.UTC)},
+		{"today 3:30pm+1h", time.Date(2020, time.November, 18, 16, 30, 0, 0, time.UTC)},
+		{"today 3:30pm -15m", time.Date(2020, time.November, 18, 15, 15, 0, 0, time.UTC)},
+		{"next monday 9:15am", time.Date(2020, time.November, 23, 9, 15, 0, 0, time.UTC)},
+		{"today 3h", time.Date(2020, time.November, 18, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := parseNow(now, "", c.value, nil, nil, nil)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("clock out of range", func(t *testing.T) {
+		for _, value := range []string{"today T24:00", "today T12:60", "today T12:00:60"} {
+			_, err := parseNow(now, "", value, nil, nil, nil)
+			ensureError(t, err, "field out of range")
+		}
+	})
+
+	t.Run("malformed clock", func(t *testing.T) {
+		for _, value := range []string{"today T15", "today T1:30", "today T15:30:00:00"} {
+			_, err := parseNow(now, "", value, nil, nil, nil)
+			ensureError(t, err, "expected HH:MM or HH:MM:SS")
+		}
+		_, err := parseNow(now, "", "today T1:30", nil, nil, nil)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 6 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 6)
+		}
+	})
+
+	t.Run("12-hour clock out of range", func(t *testing.T) {
+		for _, value := range []string{"today 0:30am", "today 13:00pm", "today 12:60pm", "today 12:00:60am"} {
+			_, err := parseNow(now, "", value, nil, nil, nil)
+			ensureError(t, err, "field out of range")
+		}
+	})
+
+	t.Run("malformed 12-hour clock", func(t *testing.T) {
+		for _, value := range []string{"today 3:3pm", "today 123pm", "today 3::30pm", "today 3:30:00:00pm"} {
+			_, err := parseNow(now, "", value, nil, nil, nil)
+			ensureError(t, err, "expected H, H:MM, or H:MM:SS followed by am or pm")
+		}
+		_, err := parseNow(now, "", "today 3:3pm", nil, nil, nil)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 6 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 6)
+		}
+	})
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := parseNow(now, "", "today T15:30+1x", nil, nil, nil)
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 14 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 14)
+		}
+	})
+
+	t.Run("longer word", func(t *testing.T) {
+		_, err := parseNow(now, time.RFC3339, "todays", nil, nil, nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseNowOrdinalOfMonth(t *testing.T) {
+	now := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"1st of next month", time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"the 1st of next month", time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"2nd of this month", time.Date(2020, time.November, 2, 0, 0, 0, 0, time.UTC)},
+		{"23rd of last month", time.Date(2020, time.October, 23, 0, 0, 0, 0, time.UTC)},
+		{"31st of next month", time.Date(2020, time.December, 31, 0, 0, 0, 0, time.UTC)},
+		{"15th of next month+9h", time.Date(2020, time.December, 15, 9, 0, 0, 0, time.UTC)},
+		{"15th of next month T09:30", time.Date(2020, time.December, 15, 9, 30, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := parseNow(now, "", c.value, nil, nil, nil)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("year boundary", func(t *testing.T) {
+		december := time.Date(2020, time.December, 18, 0, 0, 0, 0, time.UTC)
+		actual, err := parseNow(december, "", "1st of next month", nil, nil, nil)
+		ensureError(t, err)
+		if expected := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC); actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		_, err := parseNow(now, "", "31st of this month", nil, nil, nil)
+		ensureError(t, err, "day 31 out of range for November 2020")
+		_, err = parseNow(now, "", "the 0th of next month", nil, nil, nil)
+		ensureError(t, err, "day 0 out of range for December 2020")
+		if e, ok := err.(*ParseError); !ok || e.Offset != 4 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 4)
+		}
+	})
+
+	t.Run("not an ordinal", func(t *testing.T) {
+		for _, value := range []string{"1st of next week", "1st next month", "1st of next months"} {
+			_, err := parseNow(now, time.RFC3339, value, nil, nil, nil)
+			if _, ok := err.(*time.ParseError); err == nil || !ok {
+				t.Errorf("Value: %q; Actual: %#v; Expected: %T", value, err, &time.ParseError{})
+			}
+		}
+	})
+}
+
+func TestParseNowCalendarAnchors(t *testing.T) {
+	// Wednesday
+	now := time.Date(2020, time.November, 18, 15, 4, 5, 6, time.UTC)
+	end := func(t time.Time) time.Time { return t.Add(-time.Nanosecond) }
+
+	cases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"bod", time.Date(2020, time.November, 18, 0, 0, 0, 0, time.UTC)},
+		{"eod", end(time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC))},
+		{"bow", time.Date(2020, time.November, 16, 0, 0, 0, 0, time.UTC)},
+		{"eow", end(time.Date(2020, time.November, 23, 0, 0, 0, 0, time.UTC))},
+		{"bom", time.Date(2020, time.November, 1, 0, 0, 0, 0, time.UTC)},
+		{"eom", end(time.Date(2020, time.December, 1, 0, 0, 0, 0, time.UTC))},
+		{"boy", time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"eoy", end(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC))},
+		{"bod+9h", time.Date(2020, time.November, 18, 9, 0, 0, 0, time.UTC)},
+		{"bom-1mo", time.Date(2020, time.October, 1, 0, 0, 0, 0, time.UTC)},
+		{"eod +1ns", time.Date(2020, time.November, 19, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.value, func(t *testing.T) {
+			actual, err := parseNow(now, "", c.value, nil, nil, nil)
+			ensureError(t, err)
+			if actual != c.expected {
+				t.Errorf("Actual: %s; Expected: %s", actual, c.expected)
+			}
+		})
+	}
+
+	t.Run("duration error", func(t *testing.T) {
+		_, err := parseNow(now, "", "bom+1x", nil, nil, nil)
+		ensureError(t, err, `unknown unit "x"`)
+		if e, ok := err.(*ParseError); !ok || e.Offset != 5 {
+			t.Errorf("GOT: %#v; WANT: offset %d", err, 5)
+		}
+	})
+
+	t.Run("longer word", func(t *testing.T) {
+		_, err := parseNow(now, time.RFC3339, "boys", nil, nil, nil)
+		if _, ok := err.(*time.ParseError); err == nil || !ok {
+			t.Errorf("Actual: %#v; Expected: %T", err, &time.ParseError{})
+		}
+	})
+}
+
+func TestParseNowUTC(t *testing.T) {
+	before := time.Now().UTC().Add(24 * time.Hour)
+	actual, err := ParseNowUTC("", "now+1d")
+	if err != nil {
+		t.Errorf("Actual: %#v; Expected: %#v", err, nil)
+	}
+	after := time.Now().UTC().Add(24 * time.Hour)
+	if actual.Location() != time.UTC {
+		t.Errorf("Actual: %s; Expected: %s", actual.Location(), time.UTC)
+	}
+	if before.After(actual) || actual.After(after) {
+		t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+	}
+}
+
+func TestParseNowContext(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	ensureError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("now", func(t *testing.T) {
+		before := time.Now().Add(time.Hour)
+		actual, err := ParseNowContext(ctx, "", "now+1h", loc)
+		ensureError(t, err)
+		after := time.Now().Add(time.Hour)
+		if actual.Location() != loc {
+			t.Errorf("Actual: %s; Expected: %s", actual.Location(), loc)
+		}
+		if before.After(actual) || actual.After(after) {
+			t.Errorf("Actual: %s; Expected between: %s and %s", actual, before, after)
+		}
+	})
+
+	t.Run("layout", func(t *testing.T) {
+		actual, err := ParseNowContext(ctx, "2006-01-02 15:04", "2020-11-20 08:00", loc)
+		ensureError(t, err)
+		if expected := time.Date(2020, time.November, 20, 13, 0, 0, 0, time.UTC); !actual.Equal(expected) {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+}
+
+func TestBetween(t *testing.T) {
+	t.Run("now", func(t *testing.T) {
+		actual, err := Between("", "now-1h", "now", nil)
+		ensureError(t, err)
+		if expected := time.Hour; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("dict", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		actual, err := Between(time.RFC3339, "start", "2009-11-11T01:30:00Z", map[string]time.Time{"start": start})
+		ensureError(t, err)
+		if expected := 150 * time.Minute; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("dict key starting with now", func(t *testing.T) {
+		deploy := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		dict := map[string]time.Time{"now_deploy": deploy}
+		actual, err := Between("", "now_deploy", "now_deploy+1h", dict)
+		ensureError(t, err)
+		if expected := time.Hour; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+		// "now" alone is still the current time.
+		expected := time.Since(deploy)
+		actual, err = Between("", "now_deploy", "now", dict)
+		ensureError(t, err)
+		if actual < expected {
+			t.Errorf("Actual: %s; Expected: at least %s", actual, expected)
+		}
+	})
+
+	t.Run("dict key named like an anchor", func(t *testing.T) {
+		start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+		dict := map[string]time.Time{"today": start, "bod": start.Add(time.Hour)}
+		actual, err := Between(time.RFC3339, "today", "bod+30m", dict)
+		ensureError(t, err)
+		if expected := 90 * time.Minute; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		actual, err := Between("", "now", "now-1d", nil)
+		ensureError(t, err)
+		if expected := -24 * time.Hour; actual != expected {
+			t.Errorf("Actual: %s; Expected: %s", actual, expected)
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		_, err := Between("", "now", "now+1x", nil)
+		ensureError(t, err, `unknown unit "x"`)
+	})
+}
+
 // Parse
 
 func TestParseLayout(t *testing.T) {